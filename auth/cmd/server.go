@@ -99,6 +99,7 @@ func (server *AuthServer) Me(w http.ResponseWriter, r *http.Request) {
 	}
 	defer resp.Body.Close()
 	w.Header().Set("Content-Type", "application/json")
+	copyRetryAfter(w, resp)
 	w.WriteHeader(resp.StatusCode)
 	if _, err := io.Copy(w, resp.Body); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -137,6 +138,7 @@ func (server *AuthServer) createPlaylist(w http.ResponseWriter, r *http.Request)
 	}
 	defer resp.Body.Close()
 	w.Header().Set("Content-Type", "application/json")
+	copyRetryAfter(w, resp)
 	w.WriteHeader(resp.StatusCode)
 	if _, err := io.Copy(w, resp.Body); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -166,6 +168,43 @@ func (server *AuthServer) getPlaylists(w http.ResponseWriter, r *http.Request) {
 	}
 	defer resp.Body.Close()
 	w.Header().Set("Content-Type", "application/json")
+	copyRetryAfter(w, resp)
+	w.WriteHeader(resp.StatusCode)
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (server *AuthServer) Playlist(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "GET" {
+		server.getPlaylist(w, r)
+	}
+}
+
+func (server *AuthServer) getPlaylist(w http.ResponseWriter, r *http.Request) {
+	if server.client == nil {
+		server.client = &http.Client{}
+	}
+	token, err := server.source.Token()
+	if err != nil {
+		http.Error(w, "Could not retrieve token", http.StatusForbidden)
+		return
+	}
+	endpoint := r.URL.RequestURI()
+	req, err := http.NewRequest("GET", API+endpoint, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	resp, err := server.client.Do(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer resp.Body.Close()
+	w.Header().Set("Content-Type", "application/json")
+	copyRetryAfter(w, resp)
 	w.WriteHeader(resp.StatusCode)
 	if _, err := io.Copy(w, resp.Body); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -179,6 +218,12 @@ func (server *AuthServer) Tracks(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "POST" {
 		server.addTracks(w, r)
 	}
+	if r.Method == "PUT" {
+		server.replaceTracks(w, r)
+	}
+	if r.Method == "DELETE" {
+		server.removeTracks(w, r)
+	}
 }
 
 func (server *AuthServer) getTracks(w http.ResponseWriter, r *http.Request) {
@@ -204,6 +249,7 @@ func (server *AuthServer) getTracks(w http.ResponseWriter, r *http.Request) {
 	}
 	defer resp.Body.Close()
 	w.Header().Set("Content-Type", "application/json")
+	copyRetryAfter(w, resp)
 	w.WriteHeader(resp.StatusCode)
 	if _, err := io.Copy(w, resp.Body); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -233,12 +279,176 @@ func (server *AuthServer) addTracks(w http.ResponseWriter, r *http.Request) {
 	}
 	defer resp.Body.Close()
 	w.Header().Set("Content-Type", "application/json")
+	copyRetryAfter(w, resp)
 	w.WriteHeader(resp.StatusCode)
 	if _, err := io.Copy(w, resp.Body); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
+func (server *AuthServer) removeTracks(w http.ResponseWriter, r *http.Request) {
+	if server.client == nil {
+		server.client = &http.Client{}
+	}
+	token, err := server.source.Token()
+	if err != nil {
+		http.Error(w, "Could not retrieve token", http.StatusForbidden)
+		return
+	}
+	endpoint := r.URL.RequestURI()
+	req, err := http.NewRequest("DELETE", API+endpoint, r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	resp, err := server.client.Do(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer resp.Body.Close()
+	w.Header().Set("Content-Type", "application/json")
+	copyRetryAfter(w, resp)
+	w.WriteHeader(resp.StatusCode)
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (server *AuthServer) replaceTracks(w http.ResponseWriter, r *http.Request) {
+	if server.client == nil {
+		server.client = &http.Client{}
+	}
+	token, err := server.source.Token()
+	if err != nil {
+		http.Error(w, "Could not retrieve token", http.StatusForbidden)
+		return
+	}
+	endpoint := r.URL.RequestURI()
+	req, err := http.NewRequest("PUT", API+endpoint, r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	resp, err := server.client.Do(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer resp.Body.Close()
+	w.Header().Set("Content-Type", "application/json")
+	copyRetryAfter(w, resp)
+	w.WriteHeader(resp.StatusCode)
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (server *AuthServer) Followers(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "DELETE" {
+		server.unfollowPlaylist(w, r)
+	}
+}
+
+func (server *AuthServer) unfollowPlaylist(w http.ResponseWriter, r *http.Request) {
+	if server.client == nil {
+		server.client = &http.Client{}
+	}
+	token, err := server.source.Token()
+	if err != nil {
+		http.Error(w, "Could not retrieve token", http.StatusForbidden)
+		return
+	}
+	endpoint := r.URL.RequestURI()
+	req, err := http.NewRequest("DELETE", API+endpoint, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	resp, err := server.client.Do(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer resp.Body.Close()
+	w.Header().Set("Content-Type", "application/json")
+	copyRetryAfter(w, resp)
+	w.WriteHeader(resp.StatusCode)
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (server *AuthServer) Search(w http.ResponseWriter, r *http.Request) {
+	if server.client == nil {
+		server.client = &http.Client{}
+	}
+	token, err := server.source.Token()
+	if err != nil {
+		http.Error(w, "Could not retrieve token", http.StatusForbidden)
+		return
+	}
+	endpoint := r.URL.RequestURI()
+	req, err := http.NewRequest("GET", API+endpoint, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	resp, err := server.client.Do(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer resp.Body.Close()
+	w.Header().Set("Content-Type", "application/json")
+	copyRetryAfter(w, resp)
+	w.WriteHeader(resp.StatusCode)
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (server *AuthServer) SeveralTracks(w http.ResponseWriter, r *http.Request) {
+	if server.client == nil {
+		server.client = &http.Client{}
+	}
+	token, err := server.source.Token()
+	if err != nil {
+		http.Error(w, "Could not retrieve token", http.StatusForbidden)
+		return
+	}
+	endpoint := r.URL.RequestURI()
+	req, err := http.NewRequest("GET", API+endpoint, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	resp, err := server.client.Do(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer resp.Body.Close()
+	w.Header().Set("Content-Type", "application/json")
+	copyRetryAfter(w, resp)
+	w.WriteHeader(resp.StatusCode)
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// copyRetryAfter passes on how long Spotify asks to wait after rate limiting a request.
+func copyRetryAfter(w http.ResponseWriter, resp *http.Response) {
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		w.Header().Set("Retry-After", retryAfter)
+	}
+}
+
 func GetRandomString() string {
 	return uuid.NewString()
 }
@@ -288,7 +498,11 @@ func main() {
 
 	r.HandleFunc("/me", server.Me)
 	r.HandleFunc("/users/{user}/playlists", server.Playlists)
+	r.HandleFunc("/playlists/{playlist}", server.Playlist)
 	r.HandleFunc("/playlists/{playlist}/tracks", server.Tracks)
+	r.HandleFunc("/playlists/{playlist}/followers", server.Followers)
+	r.HandleFunc("/search", server.Search)
+	r.HandleFunc("/tracks", server.SeveralTracks)
 
 	log.Print("Listening on http://localhost:3000")
 	log.Fatal(http.ListenAndServe(":3000", r))
@@ -1,13 +1,28 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
+	"io"
+	"log"
+	"maps"
 	"net/http"
 	"os"
+	"os/signal"
 	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/alecthomas/kong"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/mhborthwick/mergify/pkg/schedule"
 	"github.com/mhborthwick/mergify/pkg/spotify"
 )
 
@@ -21,46 +36,1050 @@ var style = lipgloss.NewStyle().
 
 var cli CLI
 
+// out receives the text output, which --output=json discards.
+var out io.Writer = os.Stdout
+
+// display shows the progress of requests, unless --output=json is set.
+var display *progressDisplay
+
+// Spotify limits you to max 100 URIs per request.
+const batchSize = 100
+
+// maxBackoff is the longest watch waits before retrying a failed sync.
+const maxBackoff = time.Hour
+
+type Filter struct {
+	NoExplicit     bool          `json:"no_explicit" help:"Excludes explicit tracks"`
+	MinDuration    time.Duration `json:"min_duration" help:"Excludes tracks shorter than this, e.g. 1m30s"`
+	MaxDuration    time.Duration `json:"max_duration" help:"Excludes tracks longer than this, e.g. 10m"`
+	MinYear        int           `json:"min_year" help:"Excludes tracks released before this year"`
+	MaxYear        int           `json:"max_year" help:"Excludes tracks released after this year"`
+	AddedAfter     time.Time     `json:"added_after" help:"Excludes tracks added to their playlist before this date (YYYY-MM-DD)" format:"2006-01-02"`
+	AddedBefore    time.Time     `json:"added_before" help:"Excludes tracks added to their playlist after this date (YYYY-MM-DD)" format:"2006-01-02"`
+	Artists        []string      `json:"artists" help:"Only includes tracks by these artists"`
+	ExcludeArtists []string      `json:"exclude_artists" help:"Excludes tracks by these artists"`
+}
+
+func (f Filter) toSpotify() spotify.Filter {
+	return spotify.Filter{
+		NoExplicit:     f.NoExplicit,
+		MinDuration:    f.MinDuration,
+		MaxDuration:    f.MaxDuration,
+		MinYear:        f.MinYear,
+		MaxYear:        f.MaxYear,
+		AddedAfter:     f.AddedAfter,
+		AddedBefore:    f.AddedBefore,
+		Artists:        f.Artists,
+		ExcludeArtists: f.ExcludeArtists,
+	}
+}
+
+// Merge holds the flags shared by the commands that merge playlists.
+type Merge struct {
+	Mode       string `help:"Set operation used to combine the playlists (${enum})" enum:"union,intersect,subtract,xor" default:"union"`
+	Dedupe     string `help:"How duplicate tracks are identified (${enum})" enum:"uri,isrc,fuzzy" default:"uri"`
+	DedupeKeep string `help:"Which version of a duplicate track to keep (${enum})" enum:"first,popular,earliest" default:"first"`
+	// Local files and unavailable tracks are always skipped.
+	IncludeEpisodes bool   `help:"Includes podcast episodes in the merge"`
+	Filter          Filter `embed:"" prefix:"filter." group:"Filters"`
+}
+
+// duplicates counts the tracks the dedupe flags drop from the playlists.
+func (m Merge) duplicates(sources [][]spotify.PlaylistTrack) (int, error) {
+	m.Mode = string(spotify.ModeUnion)
+	union, err := m.combine(sources)
+	if err != nil {
+		return 0, err
+	}
+	return spotify.CountTracks(sources) - len(union), nil
+}
+
+// combine applies the merge mode and dedupe flags to the tracks of each playlist.
+func (m Merge) combine(sources [][]spotify.PlaylistTrack) ([]spotify.PlaylistTrack, error) {
+	return spotify.Combine(
+		spotify.Mode(m.Mode),
+		sources,
+		spotify.Dedupe(m.Dedupe),
+		spotify.Keep(m.DedupeKeep),
+	)
+}
+
+// Create holds the flags of create, which pick shares.
+type Create struct {
+	Merge  `embed:""`
+	DryRun bool   `help:"Prints the merge plan without creating a playlist"`
+	Order  string `help:"Order of the tracks in the new playlist (${enum})" enum:"source,interleave,shuffle,added-at,release-date" default:"source"`
+	Seed   int64  `help:"Seed for --order=shuffle, random if not set"`
+	Tracks bool   `help:"Prints the merged tracks as a table"`
+	Split  bool   `help:"Splits merges larger than a playlist can hold into numbered playlists"`
+	Export string `help:"Writes the merged tracks to a file (.csv, .json, .m3u8 or .xspf)" type:"path" placeholder:"FILE"`
+	// Kept playlists can be completed with mergify resume.
+	KeepPartial bool `help:"Keeps the playlists created before a failure instead of deleting them, to resume later"`
+}
+
 type CLI struct {
-	Token     string   `json:"token" hidden:""`
-	Playlists []string `json:"playlists" hidden:""`
-	Create    struct {
-	} `cmd:"" help:"Combines the tracks from the playlists in your CLI config into a new playlist"`
+	Token     string           `json:"token" hidden:""`
+	Playlists []spotify.Source `json:"playlists" hidden:""`
+	Exclude   []spotify.Source `json:"exclude" hidden:""`
+	Strict    bool             `json:"strict" help:"Fails if a playlist in your CLI config is missing or ambiguous"`
+	NoCache   bool             `json:"no_cache" help:"Fetches every playlist from Spotify instead of serving unchanged ones from ~/.mergify/cache"`
+	Output    string           `json:"output" help:"Output format (${enum})" enum:"text,json" default:"text"`
+	// Requests are retried when rate limited or on server and network errors.
+	MaxAttempts int           `json:"max_attempts" help:"Number of times a request is sent before giving up" default:"5"`
+	RetryBudget time.Duration `json:"retry_budget" help:"Longest total wait between the attempts of a request" default:"2m"`
+	Create      Create        `cmd:"" help:"Combines the tracks from the playlists in your CLI config into a new playlist"`
+	Pick        struct {
+		Create `embed:""`
+	} `cmd:"" help:"Picks playlists to merge or to save to your CLI config from your library"`
+	Resume struct{} `cmd:"" help:"Resumes the last create --keep-partial that failed while adding tracks"`
+	Sync   struct {
+		Merge  `embed:""`
+		Target string `help:"Name or ID of the playlist to sync the tracks into" required:""`
+	} `cmd:"" help:"Syncs the tracks from the playlists in your CLI config into an existing playlist"`
+	Watch struct {
+		Merge  `embed:""`
+		Target string        `help:"Name or ID of the playlist to keep in sync" required:""`
+		Every  time.Duration `help:"How often to check the playlists for changes, e.g. 6h"`
+		Cron   string        `help:"Cron expression for when to check the playlists for changes, e.g. \"0 */6 * * *\""`
+	} `cmd:"" help:"Keeps syncing the playlists in your CLI config into an existing playlist on a schedule"`
+	Export struct {
+		Playlist string `arg:"" help:"Name, URL, URI or ID of the playlist to export"`
+		File     string `arg:"" help:"File to write the tracks to (.csv, .json, .m3u8 or .xspf)" type:"path"`
+	} `cmd:"" help:"Exports the tracks of a playlist to a file"`
+	Backup struct {
+		Playlists []string `arg:"" optional:"" help:"Names, URLs, URIs or IDs of the playlists to back up"`
+		All       bool     `help:"Backs up every playlist in your library"`
+	} `cmd:"" help:"Backs up playlists to ~/.mergify/backups"`
+	Restore struct {
+		File    string `arg:"" type:"existingfile" help:"Backup file to restore"`
+		Replace bool   `help:"Replaces the contents of the backed up playlist instead of creating a new one"`
+	} `cmd:"" help:"Restores a playlist from a backup"`
+	Cache struct {
+		Clear struct{} `cmd:"" help:"Removes every cached playlist"`
+	} `cmd:"" help:"Manages the playlists cached in ~/.mergify/cache"`
+	Dedupe struct {
+		Playlist string `arg:"" help:"Name, URL, URI or ID of the playlist to dedupe"`
+		By       string `help:"How duplicate tracks are identified (${enum})" enum:"uri,isrc" default:"uri"`
+		DryRun   bool   `help:"Prints the duplicates without removing them"`
+	} `cmd:"" help:"Removes later occurrences of duplicate tracks from a playlist"`
+}
+
+// resolveFileSources makes the paths of file sources relative to the config directory.
+func resolveFileSources(configDir string) {
+	for i, source := range cli.Playlists {
+		if source.File != "" && !filepath.IsAbs(source.File) {
+			cli.Playlists[i].File = filepath.Join(configDir, source.File)
+		}
+	}
+}
+
+func newSpotify(configDir string) spotify.Spotify {
+	s := spotify.Spotify{}
+	s.Token = cli.Token
+	s.Client = &http.Client{}
+	if !cli.NoCache {
+		s.Cache = &spotify.Cache{Dir: filepath.Join(configDir, "cache")}
+	}
+	retry := spotify.DefaultRetryPolicy
+	retry.MaxAttempts = cli.MaxAttempts
+	retry.Budget = cli.RetryBudget
+	s.Retry = &retry
+	s.Progress = func(event spotify.ProgressEvent) {
+		completed.record(event)
+		if display != nil {
+			display.report(event)
+		}
+	}
+	return s
+}
+
+/*
+resolvePlaylists matches the playlists in the user's CLI config,
+warning about missing or ambiguous names, or failing with --strict.
+*/
+func resolvePlaylists(
+	ctx context.Context,
+	s *spotify.Spotify,
+	userID string,
+) (*spotify.PlaylistMatches, error) {
+	matches, err := s.GetPlaylistIDsByNameContext(ctx, userID, cli.Playlists, cli.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	for _, missing := range matches.Missing {
+		msg := fmt.Sprintf("playlist not found: %q", missing.Name)
+		if len(missing.Suggestions) > 0 {
+			var suggestions []string
+			for _, name := range missing.Suggestions {
+				suggestions = append(suggestions, fmt.Sprintf("%q", name))
+			}
+			msg += fmt.Sprintf(" (did you mean %s?)", strings.Join(suggestions, " or "))
+		}
+		warn("%s", msg)
+	}
+	for _, ambiguous := range matches.Ambiguous {
+		warn(
+			"playlist name %q matches %d playlists (%s), using %s",
+			ambiguous.Name,
+			len(ambiguous.IDs),
+			strings.Join(ambiguous.IDs, ", "),
+			ambiguous.IDs[0],
+		)
+	}
+	if cli.Strict && (len(matches.Missing) > 0 || len(matches.Ambiguous) > 0) {
+		return nil, withCode(codeUnresolvedPlaylists, errors.New("missing or ambiguous playlists in config"))
+	}
+	return matches, nil
+}
+
+/*
+findPlaylists matches playlists given on the command line by name,
+URL, URI or ID, failing if any of them is missing or ambiguous.
+*/
+func findPlaylists(
+	ctx context.Context,
+	s *spotify.Spotify,
+	userID string,
+	playlists []string,
+) *spotify.PlaylistMatches {
+	var sources []spotify.Source
+	for _, playlist := range playlists {
+		sources = append(sources, spotify.Source{Name: playlist})
+	}
+	matches, err := s.GetPlaylistIDsByNameContext(ctx, userID, sources, nil)
+	ExitIfError(err)
+	if len(matches.Missing) > 0 {
+		missing := matches.Missing[0]
+		msg := fmt.Sprintf("playlist not found: %q", missing.Name)
+		if len(missing.Suggestions) > 0 {
+			msg += fmt.Sprintf(" (did you mean %q?)", missing.Suggestions[0])
+		}
+		ExitIfError(withCode(codePlaylistNotFound, errors.New(msg)))
+	}
+	if len(matches.Ambiguous) > 0 {
+		ExitIfError(&spotify.AmbiguousPlaylistError{AmbiguousPlaylist: matches.Ambiguous[0]})
+	}
+	return matches
+}
+
+// syncResult is what syncPlaylist merged and changed in the target playlist.
+type syncResult struct {
+	PlaylistID string
+	Added      int
+	Removed    int
+	Sources    [][]spotify.PlaylistTrack
+	Tracks     []spotify.PlaylistTrack
+	Filtered   []spotify.FilterResult
+	Skipped    []spotify.SkippedItem
+}
+
+/*
+syncPlaylist merges the tracks of the matched playlists and adds and
+removes tracks in the target playlist until it holds the merge.
+*/
+func syncPlaylist(
+	ctx context.Context,
+	s *spotify.Spotify,
+	userID string,
+	matches *spotify.PlaylistMatches,
+	merge Merge,
+	target string,
+) (*syncResult, error) {
+	sources, err := s.GetTracksContext(ctx, matches.Matched)
+	if err != nil {
+		return nil, err
+	}
+	sources, skipped := spotify.SkipUnmergeable(sources, merge.IncludeEpisodes)
+	tracks, err := merge.combine(sources)
+	if err != nil {
+		return nil, err
+	}
+	tracks, filtered := merge.Filter.toSpotify().Apply(tracks)
+	trackIDs := spotify.TrackURIs(tracks)
+	playlistID, err := s.GetPlaylistIDContext(ctx, userID, target)
+	if err != nil {
+		return nil, err
+	}
+	currentTrackIDs, err := s.GetPlaylistTrackIDsContext(ctx, []string{playlistID})
+	if err != nil {
+		return nil, err
+	}
+	toAdd, toRemove := spotify.DiffTracks(currentTrackIDs, trackIDs)
+	/*
+		Tracks are added before the old ones are removed, so a failed
+		sync never leaves the target with fewer tracks than before.
+		The playlist has to hold both in between.
+	*/
+	if size := len(currentTrackIDs) + len(toAdd); size > spotify.MaxPlaylistSize {
+		return nil, withCode(codePlaylistTooLarge, fmt.Errorf(
+			"%d tracks exceed the %d track limit of a playlist while syncing",
+			size,
+			spotify.MaxPlaylistSize,
+		))
+	}
+	if _, err := s.AddTracksToPlaylistContext(ctx, playlistID, toAdd, batchSize); err != nil {
+		return nil, err
+	}
+	if _, err := s.RemoveTracksFromPlaylistContext(ctx, playlistID, toRemove, batchSize); err != nil {
+		return nil, err
+	}
+	return &syncResult{
+		PlaylistID: playlistID,
+		Added:      len(toAdd),
+		Removed:    len(toRemove),
+		Sources:    sources,
+		Tracks:     tracks,
+		Filtered:   filtered,
+		Skipped:    skipped,
+	}, nil
 }
 
-func ExitIfError(err error) {
+// watchEvent is logged for each watch cycle, as a JSON line with --output=json.
+type watchEvent struct {
+	Time     time.Time       `json:"time"`
+	Event    string          `json:"event"`
+	Sources  int             `json:"sources,omitempty"`
+	Playlist *playlistOutput `json:"playlist,omitempty"`
+	Added    int             `json:"added,omitempty"`
+	Removed  int             `json:"removed,omitempty"`
+	Skipped  int             `json:"skipped,omitempty"`
+	Error    *errorOutput    `json:"error,omitempty"`
+	Attempt  int             `json:"attempt,omitempty"`
+	Next     *time.Time      `json:"next,omitempty"`
+	Warnings []string        `json:"warnings,omitempty"`
+}
+
+// logEvent logs msg, or the event as a JSON line with --output=json.
+func logEvent(event watchEvent, msg string) {
+	if cli.Output != "json" {
+		log.Print(msg)
+		return
+	}
+	event.Time = time.Now().UTC()
+	if err := json.NewEncoder(os.Stdout).Encode(event); err != nil {
+		log.Print(err)
+	}
+}
+
+/*
+watch syncs the target playlist on a schedule until ctx is done,
+skipping the sync if none of the source playlists changed since the
+last one, and backing off exponentially while syncs fail.
+*/
+func watch(ctx context.Context, s *spotify.Spotify, sched schedule.Schedule) {
+	var last map[string]string
+	failures := 0
+	for {
+		snapshots, result, err := watchCycle(ctx, s, last)
+		if errors.Is(err, context.Canceled) {
+			logEvent(watchEvent{Event: "stopped"}, "sync interrupted, stopping")
+			return
+		}
+		now := time.Now()
+		var next time.Time
+		var event watchEvent
+		var msg string
+		switch {
+		case err != nil:
+			failures++
+			backoff := min(time.Minute<<min(failures-1, 6), maxBackoff)
+			next = now.Add(backoff)
+			event = watchEvent{
+				Event:   "error",
+				Error:   &errorOutput{Code: errorCode(err), Message: err.Error()},
+				Attempt: failures,
+			}
+			msg = fmt.Sprintf("error: %v (attempt %d, retrying in %s)", err, failures, backoff)
+		case result == nil:
+			failures = 0
+			next = sched.Next(now)
+			event = watchEvent{Event: "unchanged", Sources: len(snapshots)}
+			msg = fmt.Sprintf("no changes in %d source playlists", len(snapshots))
+		default:
+			failures = 0
+			last = snapshots
+			next = sched.Next(now)
+			playlist := newPlaylistOutput(result.PlaylistID, "", len(result.Tracks))
+			event = watchEvent{
+				Event:    "synced",
+				Sources:  len(snapshots),
+				Playlist: &playlist,
+				Added:    result.Added,
+				Removed:  result.Removed,
+				Skipped:  len(result.Skipped),
+			}
+			msg = fmt.Sprintf(
+				"synced %d source playlists (+%d, -%d, %d skipped): %s",
+				len(snapshots),
+				result.Added,
+				result.Removed,
+				len(result.Skipped),
+				playlist.URL,
+			)
+		}
+		if next.IsZero() {
+			event.Warnings = warnings
+			logEvent(event, msg)
+			logEvent(watchEvent{Event: "stopped"}, "schedule has no next run, stopping")
+			return
+		}
+		event.Next = &next
+		event.Warnings = warnings
+		if err == nil {
+			msg += fmt.Sprintf(", next check at %s", next.Format("2006-01-02 15:04"))
+		}
+		logEvent(event, msg)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			logEvent(watchEvent{Event: "stopped"}, "stopping")
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+/*
+watchCycle syncs the target playlist if the source snapshots differ
+from last. The result is nil if no source playlist changed.
+*/
+func watchCycle(
+	ctx context.Context,
+	s *spotify.Spotify,
+	last map[string]string,
+) (map[string]string, *syncResult, error) {
+	// Only the warnings of this cycle are logged with it.
+	warnings = nil
+	userID, err := s.GetUserIDContext(ctx)
 	if err != nil {
-		fmt.Println("error:", err)
-		os.Exit(1)
+		return nil, nil, err
+	}
+	matches, err := resolvePlaylists(ctx, s, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	snapshots, err := matches.Snapshots()
+	if err != nil {
+		return nil, nil, err
+	}
+	if last != nil && maps.Equal(snapshots, last) {
+		return snapshots, nil, nil
+	}
+	result, err := syncPlaylist(ctx, s, userID, matches, cli.Watch.Merge, cli.Watch.Target)
+	if err != nil {
+		return nil, nil, err
+	}
+	return snapshots, result, nil
+}
+
+func journalPath(configDir string) string {
+	return filepath.Join(configDir, "journal.json")
+}
+
+/*
+runJournal writes the playlists of the journal and removes it. If a
+request fails, the playlists it created are unfollowed, unless
+keepPartial is set, which keeps them and the journal for resume.
+*/
+func runJournal(
+	ctx context.Context,
+	s *spotify.Spotify,
+	journal *spotify.Journal,
+	keepPartial bool,
+) []string {
+	playlistIDs, err := s.RunJournalContext(ctx, journal)
+	if err == nil {
+		ExitIfError(journal.Remove())
+		return playlistIDs
+	}
+	if keepPartial {
+		for _, part := range journal.Parts {
+			if part.PlaylistID == "" {
+				continue
+			}
+			fmt.Fprintf(
+				out,
+				"Kept playlist %s (%s) with %d of %d batches added\n",
+				part.Name,
+				part.PlaylistID,
+				part.BatchesDone,
+				part.Batches(journal.BatchSize),
+			)
+		}
+		exitWithFailure(err, failureOutput{Resumable: true})
+	}
+	var failure failureOutput
+	// Deletes the playlists even if the run was interrupted.
+	removed, rollbackErr := s.RollbackJournalContext(context.WithoutCancel(ctx), journal)
+	for _, part := range removed {
+		fmt.Fprintf(
+			out,
+			"Deleted playlist %s (%s) with %d of %d batches added\n",
+			part.Name,
+			part.PlaylistID,
+			part.BatchesDone,
+			part.Batches(journal.BatchSize),
+		)
+		added := min(part.BatchesDone*journal.BatchSize, len(part.TrackIDs))
+		failure.Deleted = append(failure.Deleted, newPlaylistOutput(part.PlaylistID, part.Name, added))
+	}
+	if rollbackErr != nil {
+		failure.Resumable = true
+		exitWithFailure(fmt.Errorf("%w, and deleting the playlists failed: %w", err, rollbackErr), failure)
+	}
+	ExitIfError(journal.Remove())
+	exitWithFailure(err, failure)
+	return nil
+}
+
+/*
+printPlan prints what create would do with the tracks
+of the matched playlists without sending any POST.
+*/
+func printPlan(
+	opts Create,
+	matches *spotify.PlaylistMatches,
+	sources [][]spotify.PlaylistTrack,
+	tracks []spotify.PlaylistTrack,
+	filtered []spotify.FilterResult,
+) {
+	fmt.Fprintln(out, "Matched playlists:")
+	for i, playlist := range matches.Matched {
+		fmt.Fprintf(out, "  %s (%d tracks)\n", playlist.Name, len(sources[i]))
+	}
+	fmt.Fprintln(out, "Unmatched playlists:")
+	for _, missing := range matches.Missing {
+		fmt.Fprintf(out, "  %s\n", missing.Name)
+	}
+	removed := 0
+	for _, result := range filtered {
+		removed += result.Removed
+	}
+	duplicates, err := opts.duplicates(sources)
+	ExitIfError(err)
+	fmt.Fprintf(out, "Duplicates dropped: %d\n", duplicates)
+	if mode := opts.Mode; mode != string(spotify.ModeUnion) {
+		union := spotify.CountTracks(sources) - duplicates
+		fmt.Fprintf(out, "Dropped by %s: %d\n", mode, union-len(tracks)-removed)
+	}
+	for _, result := range filtered {
+		fmt.Fprintf(out, "Removed by --filter.%s: %d\n", result.Rule, result.Removed)
+	}
+	fmt.Fprintf(out, "Tracks to add: %d\n", len(tracks))
+	fmt.Fprintf(out, "Batches to send: %d\n", (len(tracks)+batchSize-1)/batchSize)
+	if len(tracks) > spotify.MaxPlaylistSize {
+		if opts.Split {
+			parts := spotify.SplitTracks(spotify.TrackURIs(tracks))
+			fmt.Fprintf(out, "Playlists to create: %d\n", len(parts))
+		} else {
+			warn(
+				"%d tracks exceed the %d track limit of a playlist, use --split",
+				len(tracks),
+				spotify.MaxPlaylistSize,
+			)
+		}
+	}
+	text := lipgloss.NewStyle().SetString("Dry run: no playlist was created").Bold(true)
+	fmt.Fprintln(out, text)
+}
+
+/*
+splitTracks returns the tracks to add to each new playlist, failing
+up front if they do not fit in a single playlist and --split is not set.
+*/
+func splitTracks(trackIDs []string, split bool) ([][]string, error) {
+	if len(trackIDs) <= spotify.MaxPlaylistSize {
+		return [][]string{trackIDs}, nil
+	}
+	if !split {
+		return nil, withCode(codePlaylistTooLarge, fmt.Errorf(
+			"%d tracks exceed the %d track limit of a playlist, use --split",
+			len(trackIDs),
+			spotify.MaxPlaylistSize,
+		))
+	}
+	return spotify.SplitTracks(trackIDs), nil
+}
+
+// printSkipped lists the playlist items that could not be merged.
+func printSkipped(matches *spotify.PlaylistMatches, skipped []spotify.SkippedItem) {
+	if len(skipped) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "Skipped items: %d\n", len(skipped))
+	for _, item := range skipped {
+		name := item.Item.Track.Name
+		if name == "" {
+			name = item.Item.Track.URI
+		}
+		if name == "" {
+			name = "(deleted)"
+		}
+		fmt.Fprintf(
+			out,
+			"  %s #%d: %s (%s)\n",
+			matches.Matched[item.Source].Name,
+			item.Position+1,
+			name,
+			item.Reason,
+		)
+	}
+}
+
+// printDuplicates lists the later occurrences of duplicate tracks.
+func printDuplicates(duplicates []spotify.DuplicateItem) {
+	fmt.Fprintf(out, "Duplicates: %d\n", len(duplicates))
+	for _, duplicate := range duplicates {
+		fmt.Fprintf(
+			out,
+			"  #%d: %s - %s (duplicate of #%d)\n",
+			duplicate.Position+1,
+			duplicate.Item.Track.ArtistNames(),
+			duplicate.Item.Track.Name,
+			duplicate.FirstPosition+1,
+		)
+	}
+}
+
+// printCreated prints the URL of each created playlist.
+func printCreated(playlistIDs []string) {
+	for _, playlistID := range playlistIDs {
+		url := fmt.Sprintf("Created playlist: %s", playlistURL(playlistID))
+		text := lipgloss.NewStyle().SetString(url).Bold(true)
+		fmt.Fprintln(out, text)
+	}
+}
+
+// printTracks prints the name, artists, album and length of each track.
+func printTracks(tracks []spotify.PlaylistTrack) {
+	t := table.New().
+		Border(lipgloss.NormalBorder()).
+		Headers("#", "Name", "Artists", "Album", "Released", "Length")
+	for i, p := range tracks {
+		t.Row(
+			fmt.Sprint(i+1),
+			p.Track.Name,
+			p.Track.ArtistNames(),
+			p.Track.Album.Name,
+			p.Track.Album.ReleaseDate,
+			p.Track.Duration().Round(time.Second).String(),
+		)
+	}
+	fmt.Fprintln(out, t)
+}
+
+/*
+create merges the tracks of the matched playlists into new playlists,
+or only prints the plan with --dry-run.
+*/
+func create(
+	ctx context.Context,
+	configDir string,
+	s *spotify.Spotify,
+	userID string,
+	matches *spotify.PlaylistMatches,
+	opts Create,
+	timer *stopwatch,
+) {
+	sources, err := s.GetTracksContext(ctx, matches.Matched)
+	ExitIfError(err)
+	timer.lap("fetch")
+	sources, skipped := spotify.SkipUnmergeable(sources, opts.IncludeEpisodes)
+	tracks, err := opts.combine(sources)
+	ExitIfError(err)
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	tracks, err = spotify.Sort(spotify.Order(opts.Order), sources, tracks, seed)
+	ExitIfError(err)
+	if opts.Order == string(spotify.OrderShuffle) {
+		fmt.Fprintf(out, "Shuffled with --seed=%d\n", seed)
+	} else {
+		seed = 0
+	}
+	tracks, filtered := opts.Filter.toSpotify().Apply(tracks)
+	timer.lap("merge")
+	if opts.Tracks {
+		printTracks(tracks)
+	}
+	output := createOutput{
+		mergeOutput: newMergeOutput(opts.Merge, matches, sources, tracks, filtered, skipped),
+		DryRun:      opts.DryRun,
+		Seed:        seed,
+		Playlists:   []playlistOutput{},
+	}
+	name := spotify.PlaylistName()
+	if opts.Export != "" {
+		err := spotify.ExportFile(opts.Export, name, tracks)
+		ExitIfError(err)
+		fmt.Fprintf(out, "Exported %d tracks to %s\n", len(tracks), opts.Export)
+		output.Export = opts.Export
+		timer.lap("export")
+	}
+	if opts.DryRun {
+		printPlan(opts, matches, sources, tracks, filtered)
+		printSkipped(matches, skipped)
+		output.Timings = timer.total()
+		output.Warnings = warnings
+		printJSON(output)
+		return
 	}
+	trackIDs := spotify.TrackURIs(tracks)
+	if len(trackIDs) == 0 {
+		ExitIfError(withCode(codeNoTracks, errors.New("no tracks found")))
+	}
+	parts, err := splitTracks(trackIDs, opts.Split)
+	ExitIfError(err)
+	if _, err := os.Stat(journalPath(configDir)); err == nil {
+		warn(`discarding the failed run "mergify resume" would continue`)
+	}
+	journal := spotify.NewJournal(journalPath(configDir), userID, batchSize)
+	for i, part := range parts {
+		journal.Parts = append(journal.Parts, spotify.JournalPart{
+			Name:     spotify.PartName(name, i+1, len(parts)),
+			TrackIDs: part,
+		})
+	}
+	playlistIDs := runJournal(ctx, s, journal, opts.KeepPartial)
+	timer.lap("write")
+	printSkipped(matches, skipped)
+	printCreated(playlistIDs)
+	for i, playlistID := range playlistIDs {
+		part := journal.Parts[i]
+		output.Playlists = append(output.Playlists, newPlaylistOutput(playlistID, part.Name, len(part.TrackIDs)))
+	}
+	output.Timings = timer.total()
+	output.Warnings = warnings
+	printJSON(output)
 }
 
 func main() {
 	homeDir, err := os.UserHomeDir()
 	ExitIfError(err)
 	pathToConfig := path.Join(homeDir, ".mergify", "config.json")
-	_, err = os.Stat(pathToConfig)
-	ExitIfError(err)
 	ctx := kong.Parse(&cli, kong.Configuration(kong.JSON, pathToConfig))
+	if cli.Output == "json" {
+		out = io.Discard
+	} else {
+		display = newProgressDisplay(os.Stdout)
+		out = display
+	}
+	_, err = os.Stat(pathToConfig)
+	ExitIfError(withCode(codeConfig, err))
+	configDir := path.Dir(pathToConfig)
+	resolveFileSources(configDir)
+	// Cancels the requests in flight on SIGINT or SIGTERM.
+	signalCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Restores default handling once interrupted, so a second Ctrl+C ends a rollback.
+	context.AfterFunc(signalCtx, stop)
 	switch ctx.Command() {
 	case "create":
-		fmt.Println(style.Render("Mergify!"))
-		s := spotify.Spotify{}
-		s.Token = cli.Token
-		s.Client = &http.Client{}
-		userID, err := s.GetUserID()
+		fmt.Fprintln(out, style.Render("Mergify!"))
+		timer := newStopwatch()
+		if cli.Create.Export != "" {
+			_, err := spotify.FormatFromPath(cli.Create.Export)
+			ExitIfError(withCode(codeInvalidArgument, err))
+		}
+		s := newSpotify(configDir)
+		userID, err := s.GetUserIDContext(signalCtx)
 		ExitIfError(err)
-		playlistIDs, err := s.GetPlaylistIDsByName(userID, cli.Playlists)
+		matches, err := resolvePlaylists(signalCtx, &s, userID)
 		ExitIfError(err)
-		trackIDs, err := s.GetPlaylistTrackIDs(playlistIDs)
+		timer.lap("resolve")
+		create(signalCtx, configDir, &s, userID, matches, cli.Create, timer)
+	case "pick":
+		if cli.Pick.Export != "" {
+			_, err := spotify.FormatFromPath(cli.Pick.Export)
+			ExitIfError(withCode(codeInvalidArgument, err))
+		}
+		if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+			ExitIfError(withCode(codeInvalidArgument, errors.New("pick needs an interactive terminal")))
+		}
+		s := newSpotify(configDir)
+		userID, err := s.GetUserIDContext(signalCtx)
 		ExitIfError(err)
-		playlistID, err := s.CreatePlaylist(userID, trackIDs)
+		library, err := s.GetPlaylistsContext(signalCtx, userID)
 		ExitIfError(err)
-		_, err = s.AddTracksToPlaylist(playlistID, trackIDs, 100)
+		/*
+			Preselects the playlists named in the config. Playlists matched
+			by patterns are left to their entries, which saving keeps.
+		*/
+		var named []spotify.Source
+		for _, source := range cli.Playlists {
+			if source.Name != "" {
+				named = append(named, source)
+			}
+		}
+		selectedIDs := make(map[string]bool)
+		if matches, err := s.MatchPlaylistsContext(signalCtx, library, named, nil); err == nil {
+			for _, playlist := range matches.Matched {
+				selectedIDs[playlist.ID] = true
+			}
+		}
+		// The picker draws on stderr, so stdout only has the results.
+		picker := newPicker(library, selectedIDs)
+		_, err = tea.NewProgram(picker, tea.WithOutput(os.Stderr)).Run()
 		ExitIfError(err)
-		url := fmt.Sprintf("Created playlist: https://open.spotify.com/playlist/%s", playlistID)
+		picked := picker.selected()
+		switch picker.action {
+		case pickMerge:
+			fmt.Fprintln(out, style.Render("Mergify!"))
+			matches := &spotify.PlaylistMatches{Matched: picked}
+			create(signalCtx, configDir, &s, userID, matches, cli.Pick.Create, newStopwatch())
+		case pickSave:
+			entries, kept, err := savePicked(pathToConfig, picked, library)
+			ExitIfError(withCode(codeConfig, err))
+			output := pickOutput{Config: pathToConfig, Playlists: entries}
+			for _, source := range kept {
+				output.Kept = append(output.Kept, source.String())
+			}
+			if len(kept) > 0 {
+				fmt.Fprintf(out, "Kept entries not listed by pick: %s\n", strings.Join(output.Kept, ", "))
+			}
+			msg := fmt.Sprintf("Saved playlists to %s: %s", pathToConfig, strings.Join(entries, ", "))
+			text := lipgloss.NewStyle().SetString(msg).Bold(true)
+			fmt.Fprintln(out, text)
+			printJSON(output)
+		default:
+			ExitIfError(withCode(codeCancelled, errors.New("no playlists picked")))
+		}
+	case "resume":
+		fmt.Fprintln(out, style.Render("Mergify!"))
+		timer := newStopwatch()
+		journal, err := spotify.ReadJournal(journalPath(configDir))
+		if errors.Is(err, os.ErrNotExist) {
+			ExitIfError(withCode(codeNothingToResume, errors.New("no failed run to resume")))
+		}
+		ExitIfError(err)
+		for _, part := range journal.Parts {
+			fmt.Fprintf(
+				out,
+				"Resuming %s at batch %d of %d\n",
+				part.Name,
+				part.BatchesDone+1,
+				part.Batches(journal.BatchSize),
+			)
+		}
+		s := newSpotify(configDir)
+		playlistIDs := runJournal(signalCtx, &s, journal, true)
+		timer.lap("write")
+		printCreated(playlistIDs)
+		output := resumeOutput{Playlists: []playlistOutput{}}
+		for i, playlistID := range playlistIDs {
+			part := journal.Parts[i]
+			output.Playlists = append(output.Playlists, newPlaylistOutput(playlistID, part.Name, len(part.TrackIDs)))
+		}
+		output.Timings = timer.total()
+		printJSON(output)
+	case "sync":
+		fmt.Fprintln(out, style.Render("Mergify!"))
+		timer := newStopwatch()
+		s := newSpotify(configDir)
+		userID, err := s.GetUserIDContext(signalCtx)
+		ExitIfError(err)
+		matches, err := resolvePlaylists(signalCtx, &s, userID)
+		ExitIfError(err)
+		timer.lap("resolve")
+		result, err := syncPlaylist(signalCtx, &s, userID, matches, cli.Sync.Merge, cli.Sync.Target)
+		ExitIfError(err)
+		timer.lap("sync")
+		printSkipped(matches, result.Skipped)
+		url := fmt.Sprintf(
+			"Synced playlist (+%d, -%d): %s",
+			result.Added,
+			result.Removed,
+			playlistURL(result.PlaylistID),
+		)
+		text := lipgloss.NewStyle().SetString(url).Bold(true)
+		fmt.Fprintln(out, text)
+		printJSON(syncOutput{
+			mergeOutput: newMergeOutput(
+				cli.Sync.Merge,
+				matches,
+				result.Sources,
+				result.Tracks,
+				result.Filtered,
+				result.Skipped,
+			),
+			Playlist: newPlaylistOutput(result.PlaylistID, "", len(result.Tracks)),
+			Added:    result.Added,
+			Removed:  result.Removed,
+			Timings:  timer.total(),
+			Warnings: warnings,
+		})
+	case "watch":
+		fmt.Fprintln(out, style.Render("Mergify!"))
+		var sched schedule.Schedule
+		switch {
+		case cli.Watch.Every > 0 && cli.Watch.Cron != "":
+			ExitIfError(withCode(codeInvalidArgument, errors.New("pass either --every or --cron, not both")))
+		case cli.Watch.Every > 0:
+			sched = schedule.Every(cli.Watch.Every)
+		case cli.Watch.Cron != "":
+			c, err := schedule.ParseCron(cli.Watch.Cron)
+			ExitIfError(withCode(codeInvalidArgument, err))
+			sched = c
+		default:
+			ExitIfError(withCode(codeInvalidArgument, errors.New("no schedule, pass --every or --cron")))
+		}
+		s := newSpotify(configDir)
+		// Each cycle is logged instead.
+		s.Progress = nil
+		watch(signalCtx, &s, sched)
+	case "export <playlist> <file>":
+		fmt.Fprintln(out, style.Render("Mergify!"))
+		_, err := spotify.FormatFromPath(cli.Export.File)
+		ExitIfError(withCode(codeInvalidArgument, err))
+		s := newSpotify(configDir)
+		userID, err := s.GetUserIDContext(signalCtx)
+		ExitIfError(err)
+		matches := findPlaylists(signalCtx, &s, userID, []string{cli.Export.Playlist})
+		sources, err := s.GetTracksContext(signalCtx, matches.Matched)
+		ExitIfError(err)
+		playlist := matches.Matched[0]
+		err = spotify.ExportFile(cli.Export.File, playlist.Name, sources[0])
+		ExitIfError(err)
+		msg := fmt.Sprintf("Exported %d tracks to %s", len(sources[0]), cli.Export.File)
+		text := lipgloss.NewStyle().SetString(msg).Bold(true)
+		fmt.Fprintln(out, text)
+		printJSON(exportOutput{
+			Playlist: newPlaylistOutput(playlist.ID, playlist.Name, len(sources[0])),
+			File:     cli.Export.File,
+		})
+	case "backup", "backup <playlists>":
+		fmt.Fprintln(out, style.Render("Mergify!"))
+		s := newSpotify(configDir)
+		userID, err := s.GetUserIDContext(signalCtx)
+		ExitIfError(err)
+		var playlists []spotify.Playlist
+		switch {
+		case cli.Backup.All:
+			playlists, err = s.GetPlaylistsContext(signalCtx, userID)
+			ExitIfError(err)
+		case len(cli.Backup.Playlists) > 0:
+			playlists = findPlaylists(signalCtx, &s, userID, cli.Backup.Playlists).Matched
+		default:
+			ExitIfError(withCode(codeInvalidArgument, errors.New("no playlists to back up, pass playlist names or --all")))
+		}
+		backupDir := filepath.Join(configDir, "backups", time.Now().Format("2006-01-02"))
+		output := backupOutput{Dir: backupDir, Backups: []backupFileOutput{}}
+		for _, playlist := range playlists {
+			backup, err := s.BackupPlaylistContext(signalCtx, playlist.ID)
+			ExitIfError(err)
+			backupPath, err := spotify.WriteBackup(backupDir, backup)
+			ExitIfError(err)
+			fmt.Fprintf(out, "Backed up %s (%d items) to %s\n", playlist.Name, len(backup.Items), backupPath)
+			output.Backups = append(output.Backups, backupFileOutput{
+				Playlist: newPlaylistOutput(playlist.ID, playlist.Name, len(backup.Items)),
+				File:     backupPath,
+			})
+		}
+		msg := fmt.Sprintf("Backed up %d playlists to %s", len(playlists), backupDir)
+		text := lipgloss.NewStyle().SetString(msg).Bold(true)
+		fmt.Fprintln(out, text)
+		printJSON(output)
+	case "restore <file>":
+		fmt.Fprintln(out, style.Render("Mergify!"))
+		backup, err := spotify.ReadBackup(cli.Restore.File)
+		ExitIfError(err)
+		s := newSpotify(configDir)
+		userID, err := s.GetUserIDContext(signalCtx)
+		ExitIfError(err)
+		sources, skipped := spotify.SkipUnmergeable([][]spotify.PlaylistTrack{backup.Items}, true)
+		trackIDs := spotify.TrackURIs(sources[0])
+		playlistID := backup.Playlist.ID
+		if cli.Restore.Replace {
+			snapshotID, err := s.GetSnapshotIDContext(signalCtx, playlistID)
+			ExitIfError(err)
+			if snapshotID != backup.Playlist.SnapshotID {
+				warn(
+					"playlist %q changed since it was backed up on %s",
+					backup.Playlist.Name,
+					backup.CreatedAt.Local().Format("2006-01-02 15:04"),
+				)
+			}
+			_, err = s.ReplacePlaylistTracksContext(signalCtx, playlistID, trackIDs, batchSize)
+			ExitIfError(err)
+		} else {
+			// Spotify returns descriptions HTML escaped.
+			description := html.UnescapeString(backup.Playlist.Description)
+			playlistID, err = s.CreatePlaylistWithDescriptionContext(signalCtx, userID, backup.Playlist.Name, description)
+			ExitIfError(err)
+			_, err = s.AddTracksToPlaylistContext(signalCtx, playlistID, trackIDs, batchSize)
+			ExitIfError(err)
+		}
+		matches := &spotify.PlaylistMatches{Matched: []spotify.Playlist{backup.Playlist}}
+		printSkipped(matches, skipped)
+		url := fmt.Sprintf("Restored playlist: %s", playlistURL(playlistID))
 		text := lipgloss.NewStyle().SetString(url).Bold(true)
-		fmt.Println(text)
+		fmt.Fprintln(out, text)
+		printJSON(restoreOutput{
+			Playlist: newPlaylistOutput(playlistID, backup.Playlist.Name, len(trackIDs)),
+			Replaced: cli.Restore.Replace,
+			Skipped:  newSkippedOutputs(matches, skipped),
+			Warnings: warnings,
+		})
+	case "dedupe <playlist>":
+		fmt.Fprintln(out, style.Render("Mergify!"))
+		s := newSpotify(configDir)
+		userID, err := s.GetUserIDContext(signalCtx)
+		ExitIfError(err)
+		matches := findPlaylists(signalCtx, &s, userID, []string{cli.Dedupe.Playlist})
+		playlist := matches.Matched[0]
+		// Positions are only valid for the snapshot the items were read from.
+		snapshotID, err := s.GetSnapshotIDContext(signalCtx, playlist.ID)
+		ExitIfError(err)
+		sources, err := s.GetPlaylistTracksContext(signalCtx, []string{playlist.ID})
+		ExitIfError(err)
+		currentSnapshotID, err := s.GetSnapshotIDContext(signalCtx, playlist.ID)
+		ExitIfError(err)
+		if currentSnapshotID != snapshotID {
+			ExitIfError(withCode(codePlaylistChanged, fmt.Errorf(
+				"playlist %q changed while its items were read, run dedupe again",
+				playlist.Name,
+			)))
+		}
+		duplicates, err := spotify.FindDuplicates(sources[0], spotify.Dedupe(cli.Dedupe.By))
+		ExitIfError(err)
+		printDuplicates(duplicates)
+		output := dedupeOutput{
+			Playlist:   newPlaylistOutput(playlist.ID, playlist.Name, len(sources[0])),
+			DryRun:     cli.Dedupe.DryRun,
+			Duplicates: []duplicateOutput{},
+		}
+		for _, duplicate := range duplicates {
+			output.Duplicates = append(output.Duplicates, duplicateOutput{
+				Position:      duplicate.Position,
+				FirstPosition: duplicate.FirstPosition,
+				Name:          duplicate.Item.Track.Name,
+				URI:           duplicate.Item.Track.URI,
+			})
+		}
+		if cli.Dedupe.DryRun || len(duplicates) == 0 {
+			printJSON(output)
+			return
+		}
+		positions := spotify.DuplicatePositions(duplicates)
+		_, err = s.RemovePlaylistItemsContext(signalCtx, playlist.ID, snapshotID, positions, batchSize)
+		ExitIfError(err)
+		output.Removed = len(duplicates)
+		output.Playlist.Tracks -= len(duplicates)
+		msg := fmt.Sprintf("Removed %d duplicates: %s", len(duplicates), playlistURL(playlist.ID))
+		text := lipgloss.NewStyle().SetString(msg).Bold(true)
+		fmt.Fprintln(out, text)
+		printJSON(output)
+	case "cache clear":
+		fmt.Fprintln(out, style.Render("Mergify!"))
+		cacheDir := filepath.Join(configDir, "cache")
+		err := (&spotify.Cache{Dir: cacheDir}).Clear()
+		ExitIfError(err)
+		msg := fmt.Sprintf("Cleared %s", cacheDir)
+		text := lipgloss.NewStyle().SetString(msg).Bold(true)
+		fmt.Fprintln(out, text)
+		printJSON(cacheClearOutput{Cleared: cacheDir})
 	default:
 		panic(ctx.Command())
 	}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mhborthwick/mergify/pkg/spotify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitTracks(t *testing.T) {
+	trackIDs := func(n int) []string {
+		ids := make([]string, n)
+		for i := range ids {
+			ids[i] = fmt.Sprint(i)
+		}
+		return ids
+	}
+	tests := []struct {
+		name     string
+		tracks   int
+		split    bool
+		expected []int
+		code     string
+	}{
+		{"fits", 3, false, []int{3}, ""},
+		{"at the limit", spotify.MaxPlaylistSize, false, []int{spotify.MaxPlaylistSize}, ""},
+		{"too large", spotify.MaxPlaylistSize + 1, false, nil, codePlaylistTooLarge},
+		{"split", spotify.MaxPlaylistSize*2 + 1, true, []int{spotify.MaxPlaylistSize, spotify.MaxPlaylistSize, 1}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parts, err := splitTracks(trackIDs(test.tracks), test.split)
+			if test.code != "" {
+				assert.Equal(t, test.code, errorCode(err))
+				return
+			}
+			assert.NoError(t, err)
+			var sizes []int
+			for _, part := range parts {
+				sizes = append(sizes, len(part))
+			}
+			assert.Equal(t, test.expected, sizes)
+		})
+	}
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/mhborthwick/mergify/pkg/spotify"
+)
+
+// Error codes reported with --output=json, which scripts may rely on.
+const (
+	codeError               = "error"
+	codeConfig              = "config_error"
+	codeInvalidArgument     = "invalid_argument"
+	codeUnauthorized        = "unauthorized"
+	codeRateLimited         = "rate_limited"
+	codeNotFound            = "not_found"
+	codeRequestFailed       = "request_failed"
+	codeNetwork             = "network_error"
+	codePlaylistNotFound    = "playlist_not_found"
+	codePlaylistAmbiguous   = "playlist_ambiguous"
+	codeUnresolvedPlaylists = "unresolved_playlists"
+	codeNoTracks            = "no_tracks"
+	codePlaylistTooLarge    = "playlist_too_large"
+	codePlaylistChanged     = "playlist_changed"
+	codeNothingToResume     = "nothing_to_resume"
+	codeCancelled           = "cancelled"
+	codeInterrupted         = "interrupted"
+)
+
+// codedError sets the error code of an error.
+type codedError struct {
+	code string
+	err  error
+}
+
+func (e *codedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *codedError) Unwrap() error {
+	return e.err
+}
+
+func withCode(code string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{code: code, err: err}
+}
+
+// errorCode returns the code set with withCode, or one derived from a failed request.
+func errorCode(err error) string {
+	var coded *codedError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+	var ambiguousErr *spotify.AmbiguousPlaylistError
+	if errors.As(err, &ambiguousErr) {
+		return codePlaylistAmbiguous
+	}
+	var requestErr *spotify.RequestError
+	if errors.As(err, &requestErr) {
+		switch requestErr.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return codeUnauthorized
+		case http.StatusTooManyRequests:
+			return codeRateLimited
+		case http.StatusNotFound:
+			return codeNotFound
+		default:
+			return codeRequestFailed
+		}
+	}
+	if errors.Is(err, context.Canceled) {
+		return codeInterrupted
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return codeNetwork
+	}
+	return codeError
+}
+
+type errorOutput struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// failureOutput is printed instead of the result of a failed command.
+type failureOutput struct {
+	Error errorOutput `json:"error"`
+	// Deleted lists the playlists of a failed create that were deleted again.
+	Deleted []playlistOutput `json:"deleted,omitempty"`
+	// Resumable is set if mergify resume can continue the failed create.
+	Resumable bool `json:"resumable,omitempty"`
+	// Completed is set if the run was interrupted.
+	Completed *completedWork `json:"completed,omitempty"`
+	Warnings  []string       `json:"warnings,omitempty"`
+}
+
+// completedWork counts the playlists fetched and batches sent so far.
+type completedWork struct {
+	Playlists int `json:"playlists"`
+	Tracks    int `json:"tracks"`
+	Batches   int `json:"batches"`
+}
+
+// completed is reported when SIGINT or SIGTERM interrupts a run.
+var completed completedWork
+
+func (c *completedWork) record(event spotify.ProgressEvent) {
+	switch {
+	case event.Stage == spotify.StageFetch && event.Done == event.Total:
+		c.Playlists++
+		c.Tracks += event.Total
+	case event.Stage == spotify.StageAdd || event.Stage == spotify.StageRemove:
+		c.Batches++
+	}
+}
+
+func exitWithFailure(err error, failure failureOutput) {
+	if errors.Is(err, context.Canceled) {
+		failure.Completed = &completed
+	}
+	if cli.Output == "json" {
+		failure.Error = errorOutput{Code: errorCode(err), Message: err.Error()}
+		failure.Warnings = warnings
+		printJSON(failure)
+	} else {
+		fmt.Fprintln(out, "error:", err)
+		if failure.Completed != nil {
+			fmt.Fprintln(out, "Completed before the interruption:")
+			fmt.Fprintf(out, "  Playlists fetched: %d (%d tracks)\n", completed.Playlists, completed.Tracks)
+			fmt.Fprintf(out, "  Batches sent: %d\n", completed.Batches)
+		}
+		if failure.Resumable {
+			fmt.Fprintln(out, `Run "mergify resume" to continue where this run stopped.`)
+		}
+	}
+	os.Exit(1)
+}
+
+func ExitIfError(err error) {
+	if err != nil {
+		exitWithFailure(err, failureOutput{})
+	}
+}
+
+// printJSON prints the result of a command with --output=json.
+func printJSON(v any) {
+	if cli.Output != "json" {
+		return
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// warnings collects the warnings printed by a command, for --output=json.
+var warnings []string
+
+// warn prints a warning and keeps it for the JSON output.
+func warn(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	warnings = append(warnings, msg)
+	fmt.Fprintln(out, "warning:", msg)
+}
+
+func playlistURL(playlistID string) string {
+	return "https://open.spotify.com/playlist/" + playlistID
+}
+
+type playlistOutput struct {
+	ID     string `json:"id"`
+	Name   string `json:"name,omitempty"`
+	URL    string `json:"url"`
+	Tracks int    `json:"tracks"`
+}
+
+func newPlaylistOutput(playlistID string, name string, tracks int) playlistOutput {
+	return playlistOutput{
+		ID:     playlistID,
+		Name:   name,
+		URL:    playlistURL(playlistID),
+		Tracks: tracks,
+	}
+}
+
+type skippedOutput struct {
+	Playlist string             `json:"playlist"`
+	Position int                `json:"position"`
+	Name     string             `json:"name,omitempty"`
+	URI      string             `json:"uri,omitempty"`
+	Reason   spotify.SkipReason `json:"reason"`
+}
+
+func newSkippedOutputs(matches *spotify.PlaylistMatches, skipped []spotify.SkippedItem) []skippedOutput {
+	var outputs []skippedOutput
+	for _, item := range skipped {
+		outputs = append(outputs, skippedOutput{
+			Playlist: matches.Matched[item.Source].Name,
+			Position: item.Position,
+			Name:     item.Item.Track.Name,
+			URI:      item.Item.Track.URI,
+			Reason:   item.Reason,
+		})
+	}
+	return outputs
+}
+
+// mergeOutput is what create and sync report about a merge.
+type mergeOutput struct {
+	Sources    []playlistOutput            `json:"sources"`
+	Missing    []spotify.MissingPlaylist   `json:"missing,omitempty"`
+	Ambiguous  []spotify.AmbiguousPlaylist `json:"ambiguous,omitempty"`
+	Duplicates int                         `json:"duplicates"`
+	Filtered   []spotify.FilterResult      `json:"filtered,omitempty"`
+	Skipped    []skippedOutput             `json:"skipped,omitempty"`
+	Tracks     int                         `json:"tracks"`
+}
+
+func newMergeOutput(
+	m Merge,
+	matches *spotify.PlaylistMatches,
+	sources [][]spotify.PlaylistTrack,
+	tracks []spotify.PlaylistTrack,
+	filtered []spotify.FilterResult,
+	skipped []spotify.SkippedItem,
+) mergeOutput {
+	duplicates, err := m.duplicates(sources)
+	ExitIfError(err)
+	output := mergeOutput{
+		Missing:    matches.Missing,
+		Ambiguous:  matches.Ambiguous,
+		Duplicates: duplicates,
+		Filtered:   filtered,
+		Skipped:    newSkippedOutputs(matches, skipped),
+		Tracks:     len(tracks),
+	}
+	for i, playlist := range matches.Matched {
+		output.Sources = append(output.Sources, newPlaylistOutput(playlist.ID, playlist.Name, len(sources[i])))
+	}
+	return output
+}
+
+// stopwatch times the phases of a command.
+type stopwatch struct {
+	start   time.Time
+	last    time.Time
+	timings map[string]int64
+}
+
+func newStopwatch() *stopwatch {
+	now := time.Now()
+	return &stopwatch{start: now, last: now, timings: make(map[string]int64)}
+}
+
+// lap records the time since the previous lap as phase, in milliseconds.
+func (w *stopwatch) lap(phase string) {
+	now := time.Now()
+	w.timings[phase+"_ms"] += now.Sub(w.last).Milliseconds()
+	w.last = now
+}
+
+// total returns the timings of each phase and of the whole command.
+func (w *stopwatch) total() map[string]int64 {
+	w.timings["total_ms"] = time.Since(w.start).Milliseconds()
+	return w.timings
+}
+
+type createOutput struct {
+	mergeOutput
+	DryRun    bool             `json:"dry_run"`
+	Seed      int64            `json:"seed,omitempty"`
+	Export    string           `json:"export,omitempty"`
+	Playlists []playlistOutput `json:"playlists"`
+	Timings   map[string]int64 `json:"timings"`
+	Warnings  []string         `json:"warnings,omitempty"`
+}
+
+type resumeOutput struct {
+	Playlists []playlistOutput `json:"playlists"`
+	Timings   map[string]int64 `json:"timings"`
+}
+
+type syncOutput struct {
+	mergeOutput
+	Playlist playlistOutput   `json:"playlist"`
+	Added    int              `json:"added"`
+	Removed  int              `json:"removed"`
+	Timings  map[string]int64 `json:"timings"`
+	Warnings []string         `json:"warnings,omitempty"`
+}
+
+type exportOutput struct {
+	Playlist playlistOutput `json:"playlist"`
+	File     string         `json:"file"`
+}
+
+type backupFileOutput struct {
+	Playlist playlistOutput `json:"playlist"`
+	File     string         `json:"file"`
+}
+
+type backupOutput struct {
+	Dir     string             `json:"dir"`
+	Backups []backupFileOutput `json:"backups"`
+}
+
+type restoreOutput struct {
+	Playlist playlistOutput  `json:"playlist"`
+	Replaced bool            `json:"replaced"`
+	Skipped  []skippedOutput `json:"skipped,omitempty"`
+	Warnings []string        `json:"warnings,omitempty"`
+}
+
+type duplicateOutput struct {
+	Position      int    `json:"position"`
+	FirstPosition int    `json:"first_position"`
+	Name          string `json:"name"`
+	URI           string `json:"uri"`
+}
+
+type dedupeOutput struct {
+	Playlist   playlistOutput    `json:"playlist"`
+	DryRun     bool              `json:"dry_run"`
+	Duplicates []duplicateOutput `json:"duplicates"`
+	Removed    int               `json:"removed"`
+}
+
+type cacheClearOutput struct {
+	Cleared string `json:"cleared"`
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/mhborthwick/mergify/pkg/spotify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"coded", withCode(codeConfig, errors.New("no config")), codeConfig},
+		{"wrapped code", fmt.Errorf("create: %w", withCode(codeNoTracks, errors.New("no tracks"))), codeNoTracks},
+		{"unauthorized", &spotify.RequestError{StatusCode: http.StatusUnauthorized}, codeUnauthorized},
+		{"forbidden", &spotify.RequestError{StatusCode: http.StatusForbidden}, codeUnauthorized},
+		{"rate limited", &spotify.RequestError{StatusCode: http.StatusTooManyRequests}, codeRateLimited},
+		{"not found", &spotify.RequestError{StatusCode: http.StatusNotFound}, codeNotFound},
+		{"server error", &spotify.RequestError{StatusCode: http.StatusBadGateway}, codeRequestFailed},
+		{"ambiguous", &spotify.AmbiguousPlaylistError{}, codePlaylistAmbiguous},
+		{"interrupted", fmt.Errorf("fetch: %w", context.Canceled), codeInterrupted},
+		{"network", &url.Error{Op: "Get", URL: "http://localhost:3000/me", Err: errors.New("connection refused")}, codeNetwork},
+		{"other", errors.New("boom"), codeError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, errorCode(test.err))
+		})
+	}
+}
+
+func TestCompletedWorkRecord(t *testing.T) {
+	tests := []struct {
+		name     string
+		events   []spotify.ProgressEvent
+		expected completedWork
+	}{
+		{
+			name: "fetched playlists",
+			events: []spotify.ProgressEvent{
+				{Stage: spotify.StageFetch, Done: 100, Total: 150},
+				{Stage: spotify.StageFetch, Done: 150, Total: 150},
+				{Stage: spotify.StageFetch, Done: 20, Total: 20, Cached: true},
+			},
+			expected: completedWork{Playlists: 2, Tracks: 170},
+		},
+		{
+			name: "sent batches",
+			events: []spotify.ProgressEvent{
+				{Stage: spotify.StageAdd, Done: 1, Total: 2},
+				{Stage: spotify.StageAdd, Done: 2, Total: 2},
+				{Stage: spotify.StageRemove, Done: 1, Total: 1},
+			},
+			expected: completedWork{Batches: 3},
+		},
+		{
+			name: "retries",
+			events: []spotify.ProgressEvent{
+				{Stage: spotify.StageRetry, Attempt: 2},
+			},
+			expected: completedWork{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var c completedWork
+			for _, event := range test.events {
+				c.record(event)
+			}
+			assert.Equal(t, test.expected, c)
+		})
+	}
+}
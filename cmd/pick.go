@@ -0,0 +1,266 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mhborthwick/mergify/pkg/spotify"
+)
+
+type pickOutput struct {
+	Config    string   `json:"config"`
+	Playlists []string `json:"playlists"`
+	// Kept lists the pattern, file and other entries left in the config.
+	Kept []string `json:"kept,omitempty"`
+}
+
+// pickAction is what pick does with the selected playlists.
+type pickAction int
+
+const (
+	pickCancel pickAction = iota
+	pickMerge
+	pickSave
+)
+
+// pickRows is the number of playlists pick lists at once.
+const pickRows = 15
+
+type pickItem struct {
+	playlist spotify.Playlist
+	selected bool
+}
+
+/*
+picker lists playlists to select: typing filters them by name, tab
+selects the one under the cursor and enter asks what to do with them.
+*/
+type picker struct {
+	items  []pickItem
+	filter string
+	// cursor is an index into the playlists matching the filter.
+	cursor  int
+	confirm bool
+	action  pickAction
+}
+
+func newPicker(playlists []spotify.Playlist, selectedIDs map[string]bool) *picker {
+	p := &picker{}
+	for _, playlist := range playlists {
+		p.items = append(p.items, pickItem{playlist: playlist, selected: selectedIDs[playlist.ID]})
+	}
+	return p
+}
+
+// visible returns the indexes of the playlists matching the filter.
+func (p *picker) visible() []int {
+	filter := strings.ToLower(p.filter)
+	var indexes []int
+	for i, item := range p.items {
+		if strings.Contains(strings.ToLower(item.playlist.Name), filter) {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// selected returns the selected playlists in the order of the library.
+func (p *picker) selected() []spotify.Playlist {
+	var playlists []spotify.Playlist
+	for _, item := range p.items {
+		if item.selected {
+			playlists = append(playlists, item.playlist)
+		}
+	}
+	return playlists
+}
+
+func (p *picker) Init() tea.Cmd {
+	return nil
+}
+
+func (p *picker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return p, nil
+	}
+	if key.Type == tea.KeyCtrlC {
+		return p, tea.Quit
+	}
+	if p.confirm {
+		switch key.String() {
+		case "enter", "m":
+			p.action = pickMerge
+			return p, tea.Quit
+		case "s":
+			p.action = pickSave
+			return p, tea.Quit
+		case "esc":
+			p.confirm = false
+		}
+		return p, nil
+	}
+	visible := p.visible()
+	switch key.Type {
+	case tea.KeyEsc:
+		if p.filter == "" {
+			return p, tea.Quit
+		}
+		p.filter = ""
+		p.cursor = 0
+	case tea.KeyUp, tea.KeyCtrlP:
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case tea.KeyDown, tea.KeyCtrlN:
+		if p.cursor < len(visible)-1 {
+			p.cursor++
+		}
+	case tea.KeyTab:
+		if len(visible) > 0 {
+			item := &p.items[visible[p.cursor]]
+			item.selected = !item.selected
+		}
+	case tea.KeyEnter:
+		if len(p.selected()) > 0 {
+			p.confirm = true
+		}
+	case tea.KeyBackspace:
+		if filter := []rune(p.filter); len(filter) > 0 {
+			p.filter = string(filter[:len(filter)-1])
+			p.cursor = 0
+		}
+	case tea.KeySpace:
+		p.filter += " "
+		p.cursor = 0
+	case tea.KeyRunes:
+		p.filter += string(key.Runes)
+		p.cursor = 0
+	}
+	return p, nil
+}
+
+func (p *picker) View() string {
+	var b strings.Builder
+	b.WriteString(style.Render("Mergify!") + "\n")
+	selected := len(p.selected())
+	if p.confirm {
+		fmt.Fprintf(&b, "Selected playlists: %d\n\n", selected)
+		b.WriteString("enter: merge now • s: save to ~/.mergify/config.json • esc: back\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "Filter: %s\n\n", p.filter)
+	visible := p.visible()
+	start := max(0, p.cursor-pickRows+1)
+	for row := start; row < min(len(visible), start+pickRows); row++ {
+		item := p.items[visible[row]]
+		line := "[ ] "
+		if item.selected {
+			line = "[x] "
+		}
+		line += item.playlist.Name
+		if item.playlist.Tracks != nil {
+			line += fmt.Sprintf(" (%d tracks)", item.playlist.Tracks.Total)
+		}
+		if row == p.cursor {
+			line = barStyle.Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		b.WriteString(line + "\n")
+	}
+	if len(visible) == 0 {
+		b.WriteString("  No playlists match\n")
+	}
+	fmt.Fprintf(
+		&b,
+		"\n%d of %d selected • type to filter • ↑/↓: move • tab: select • enter: done • esc: quit\n",
+		selected,
+		len(p.items),
+	)
+	return b.String()
+}
+
+/*
+savePicked replaces the playlists in the user's CLI config with the
+picked playlists, by name unless the name is used by another playlist
+in the library. Entries the picker does not list, i.e. patterns, files
+and playlists outside the library, are kept after them. It returns the
+saved and the kept entries.
+*/
+func savePicked(
+	pathToConfig string,
+	picked []spotify.Playlist,
+	library []spotify.Playlist,
+) ([]string, []spotify.Source, error) {
+	names := make(map[string]int)
+	ids := make(map[string]bool)
+	for _, playlist := range library {
+		names[playlist.Name]++
+		ids[playlist.ID] = true
+	}
+	var entries []string
+	var playlists []json.RawMessage
+	for _, playlist := range picked {
+		entry := playlist.Name
+		if names[playlist.Name] > 1 {
+			entry = playlist.ID
+		}
+		raw, err := json.Marshal(entry)
+		if err != nil {
+			return nil, nil, err
+		}
+		entries = append(entries, entry)
+		playlists = append(playlists, raw)
+	}
+	info, err := os.Stat(pathToConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := os.ReadFile(pathToConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Other settings are kept as they are.
+	var config map[string]json.RawMessage
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+	if config == nil {
+		config = make(map[string]json.RawMessage)
+	}
+	var current []json.RawMessage
+	if raw, ok := config["playlists"]; ok {
+		if err := json.Unmarshal(raw, &current); err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal playlists: %w", err)
+		}
+	}
+	var kept []spotify.Source
+	for _, raw := range current {
+		var source spotify.Source
+		if err := json.Unmarshal(raw, &source); err != nil {
+			return nil, nil, err
+		}
+		id, _ := spotify.ParsePlaylistID(source.Name)
+		if source.Name != "" && (names[source.Name] > 0 || ids[source.Name] || ids[id]) {
+			continue
+		}
+		kept = append(kept, source)
+		playlists = append(playlists, raw)
+	}
+	config["playlists"], err = json.Marshal(playlists)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err = json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := os.WriteFile(pathToConfig, append(data, '\n'), info.Mode().Perm()); err != nil {
+		return nil, nil, fmt.Errorf("failed to write config: %w", err)
+	}
+	return entries, kept, nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mhborthwick/mergify/pkg/spotify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPickerUpdate(t *testing.T) {
+	library := []spotify.Playlist{
+		{ID: "1", Name: "Road Trip"},
+		{ID: "2", Name: "Workout"},
+		{ID: "3", Name: "Road Songs"},
+	}
+	runes := func(s string) tea.KeyMsg {
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+	}
+	key := func(t tea.KeyType) tea.KeyMsg {
+		return tea.KeyMsg{Type: t}
+	}
+	tests := []struct {
+		name     string
+		selected map[string]bool
+		keys     []tea.KeyMsg
+		picked   []string
+		action   pickAction
+		quit     bool
+	}{
+		{
+			name:   "filters and selects",
+			keys:   []tea.KeyMsg{runes("road"), key(tea.KeyDown), key(tea.KeyTab), key(tea.KeyEnter), runes("m")},
+			picked: []string{"3"},
+			action: pickMerge,
+			quit:   true,
+		},
+		{
+			name:     "saves preselected playlists",
+			selected: map[string]bool{"2": true},
+			keys:     []tea.KeyMsg{key(tea.KeyEnter), runes("s")},
+			picked:   []string{"2"},
+			action:   pickSave,
+			quit:     true,
+		},
+		{
+			name:     "deselects",
+			selected: map[string]bool{"1": true, "2": true},
+			keys:     []tea.KeyMsg{key(tea.KeyTab), key(tea.KeyEnter), key(tea.KeyEnter)},
+			picked:   []string{"2"},
+			action:   pickMerge,
+			quit:     true,
+		},
+		{
+			name: "enter needs a selection",
+			keys: []tea.KeyMsg{key(tea.KeyEnter), runes("s")},
+		},
+		{
+			name:     "esc leaves the confirmation",
+			selected: map[string]bool{"1": true},
+			keys:     []tea.KeyMsg{key(tea.KeyEnter), key(tea.KeyEsc), runes("s")},
+			picked:   []string{"1"},
+		},
+		{
+			name: "esc clears the filter before quitting",
+			keys: []tea.KeyMsg{runes("x"), key(tea.KeyEsc)},
+		},
+		{
+			name: "esc quits",
+			keys: []tea.KeyMsg{key(tea.KeyEsc)},
+			quit: true,
+		},
+		{
+			name:     "ctrl+c cancels",
+			selected: map[string]bool{"1": true},
+			keys:     []tea.KeyMsg{key(tea.KeyEnter), key(tea.KeyCtrlC)},
+			picked:   []string{"1"},
+			quit:     true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := newPicker(library, test.selected)
+			var cmd tea.Cmd
+			for _, msg := range test.keys {
+				_, cmd = p.Update(msg)
+			}
+			var picked []string
+			for _, playlist := range p.selected() {
+				picked = append(picked, playlist.ID)
+			}
+			assert.Equal(t, test.picked, picked)
+			assert.Equal(t, test.action, p.action)
+			assert.Equal(t, test.quit, cmd != nil, "unexpected quit")
+		})
+	}
+
+	t.Run("filter with spaces and backspace", func(t *testing.T) {
+		p := newPicker(library, nil)
+		for _, msg := range []tea.KeyMsg{runes("road"), key(tea.KeySpace), runes("s"), key(tea.KeyBackspace), runes("so")} {
+			p.Update(msg)
+		}
+		assert.Equal(t, "road so", p.filter)
+		assert.Equal(t, []int{2}, p.visible())
+	})
+}
+
+func TestSavePicked(t *testing.T) {
+	library := []spotify.Playlist{
+		{ID: "1", Name: "Road Trip"},
+		{ID: "2", Name: "Workout"},
+		{ID: "3", Name: "Workout"},
+		{ID: "37i9dQZF1DXcBWIGoYBM5M", Name: "Today's Top Hits"},
+	}
+	writeConfig := func(t *testing.T, config string) string {
+		path := filepath.Join(t.TempDir(), "config.json")
+		assert.NoError(t, os.WriteFile(path, []byte(config), 0o600))
+		return path
+	}
+	readConfig := func(t *testing.T, path string) map[string]any {
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		var config map[string]any
+		assert.NoError(t, json.Unmarshal(data, &config))
+		return config
+	}
+
+	t.Run("replaces library playlists and keeps other entries", func(t *testing.T) {
+		path := writeConfig(t, `{
+			"token": "secret",
+			"playlists": [
+				"Road Trip",
+				"https://open.spotify.com/playlist/37i9dQZF1DXcBWIGoYBM5M",
+				{"match": "* 2024"},
+				"Someone Else's Playlist",
+				{"file": "tracks.csv"}
+			]
+		}`)
+		picked := []spotify.Playlist{library[1], library[3]}
+		entries, kept, err := savePicked(path, picked, library)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"2", "Today's Top Hits"}, entries, "expected the ID of an ambiguous name")
+		expectedKept := []spotify.Source{
+			{Match: "* 2024"},
+			{Name: "Someone Else's Playlist"},
+			{File: "tracks.csv"},
+		}
+		assert.Equal(t, expectedKept, kept)
+		config := readConfig(t, path)
+		assert.Equal(t, "secret", config["token"], "expected other settings to be kept")
+		expectedPlaylists := []any{
+			"2",
+			"Today's Top Hits",
+			map[string]any{"match": "* 2024"},
+			"Someone Else's Playlist",
+			map[string]any{"file": "tracks.csv"},
+		}
+		assert.Equal(t, expectedPlaylists, config["playlists"])
+	})
+
+	t.Run("config without playlists", func(t *testing.T) {
+		path := writeConfig(t, `{"token": "secret"}`)
+		entries, kept, err := savePicked(path, library[:1], library)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Road Trip"}, entries)
+		assert.Empty(t, kept)
+		assert.Equal(t, []any{"Road Trip"}, readConfig(t, path)["playlists"])
+	})
+
+	t.Run("keeps the file mode", func(t *testing.T) {
+		path := writeConfig(t, `{}`)
+		_, _, err := savePicked(path, library[:1], library)
+		assert.NoError(t, err)
+		info, err := os.Stat(path)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		path := writeConfig(t, `{"playlists": [`)
+		_, _, err := savePicked(path, library[:1], library)
+		assert.Error(t, err)
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"playlists": [`, string(data), "expected the config to be left alone")
+	})
+}
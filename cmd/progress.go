@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mhborthwick/mergify/pkg/spotify"
+)
+
+/*
+progressDisplay writes the text output. On a terminal it draws the
+progress of the spotify client as a status line below the output,
+otherwise it logs finished playlists and batches as plain lines.
+*/
+type progressDisplay struct {
+	w     io.Writer
+	tty   bool
+	drawn bool
+	// collected counts the tracks of the playlists fetched so far.
+	collected int
+}
+
+func newProgressDisplay(f *os.File) *progressDisplay {
+	info, err := f.Stat()
+	tty := err == nil && info.Mode()&os.ModeCharDevice != 0
+	return &progressDisplay{w: f, tty: tty}
+}
+
+// Write clears the status line before writing output.
+func (d *progressDisplay) Write(p []byte) (int, error) {
+	if d.drawn {
+		fmt.Fprint(d.w, "\r\x1b[2K")
+		d.drawn = false
+	}
+	return d.w.Write(p)
+}
+
+var barStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
+
+func progressBar(done, total int) string {
+	const width = 20
+	filled := width
+	if total > 0 {
+		// Spotify's total can be stale while pages are fetched.
+		filled = min(max(width*done/total, 0), width)
+	}
+	return barStyle.Render(strings.Repeat("█", filled)) + strings.Repeat("░", width-filled)
+}
+
+// report shows an event of the spotify client's Progress callback.
+func (d *progressDisplay) report(event spotify.ProgressEvent) {
+	if event.Stage == spotify.StageRetry {
+		fmt.Fprintf(
+			d,
+			"Retrying in %s (attempt %d of %d): %v\n",
+			event.Delay.Round(100*time.Millisecond),
+			event.Attempt,
+			cli.MaxAttempts,
+			event.Err,
+		)
+		return
+	}
+	name := event.Playlist.Name
+	if name == "" {
+		name = event.Playlist.ID
+	}
+	// Keeps the status line on one line in narrow terminals.
+	if runes := []rune(name); len(runes) > 30 {
+		name = string(runes[:29]) + "…"
+	}
+	done := event.Done == event.Total
+	var status, line string
+	switch event.Stage {
+	case spotify.StageFetch:
+		status = fmt.Sprintf(
+			"Fetching %s %s %d/%d tracks",
+			name,
+			progressBar(event.Done, event.Total),
+			event.Done,
+			event.Total,
+		)
+		if event.Pages > 0 {
+			status += fmt.Sprintf(", %d pages", event.Pages)
+		}
+		status += fmt.Sprintf(" · %d collected", d.collected+event.Done)
+		if done {
+			d.collected += event.Total
+			line = fmt.Sprintf("Fetched %s: %d tracks", name, event.Total)
+			switch {
+			case event.Cached:
+				line += " (cached)"
+			case event.Pages == 1:
+				line += " in 1 page"
+			case event.Pages > 1:
+				line += fmt.Sprintf(" in %d pages", event.Pages)
+			}
+		}
+	case spotify.StageAdd:
+		status = fmt.Sprintf(
+			"Adding to %s %s batch %d/%d",
+			name,
+			progressBar(event.Done, event.Total),
+			event.Done,
+			event.Total,
+		)
+		line = fmt.Sprintf("Added batch %d/%d to %s", event.Done, event.Total, name)
+	case spotify.StageRemove:
+		status = fmt.Sprintf(
+			"Removing from %s %s batch %d/%d",
+			name,
+			progressBar(event.Done, event.Total),
+			event.Done,
+			event.Total,
+		)
+		line = fmt.Sprintf("Removed batch %d/%d from %s", event.Done, event.Total, name)
+	}
+	if d.tty {
+		fmt.Fprint(d.w, "\r\x1b[2K"+status)
+		d.drawn = true
+		return
+	}
+	if line != "" {
+		fmt.Fprintln(d.w, line)
+	}
+}
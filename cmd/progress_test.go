@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mhborthwick/mergify/pkg/spotify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressBar(t *testing.T) {
+	tests := []struct {
+		name        string
+		done, total int
+		filled      int
+	}{
+		{"empty", 0, 10, 0},
+		{"half", 5, 10, 10},
+		{"full", 10, 10, 20},
+		{"unknown total", 3, 0, 20},
+		{"stale total", 150, 100, 20},
+		{"negative", -1, 10, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bar := progressBar(test.done, test.total)
+			assert.Equal(t, test.filled, strings.Count(bar, "█"))
+			assert.Equal(t, 20-test.filled, strings.Count(bar, "░"))
+		})
+	}
+}
+
+func TestProgressDisplayReport(t *testing.T) {
+	playlist := spotify.Playlist{ID: "123", Name: "Playlist 1"}
+	tests := []struct {
+		name     string
+		events   []spotify.ProgressEvent
+		expected string
+	}{
+		{
+			name: "fetched pages",
+			events: []spotify.ProgressEvent{
+				{Stage: spotify.StageFetch, Playlist: playlist, Pages: 1, Done: 100, Total: 150},
+				{Stage: spotify.StageFetch, Playlist: playlist, Pages: 2, Done: 150, Total: 150},
+			},
+			expected: "Fetched Playlist 1: 150 tracks in 2 pages\n",
+		},
+		{
+			name: "cached",
+			events: []spotify.ProgressEvent{
+				{Stage: spotify.StageFetch, Playlist: playlist, Done: 3, Total: 3, Cached: true},
+			},
+			expected: "Fetched Playlist 1: 3 tracks (cached)\n",
+		},
+		{
+			name: "batches by ID",
+			events: []spotify.ProgressEvent{
+				{Stage: spotify.StageAdd, Playlist: spotify.Playlist{ID: "456"}, Done: 1, Total: 2},
+				{Stage: spotify.StageRemove, Playlist: spotify.Playlist{ID: "456"}, Done: 1, Total: 1},
+			},
+			expected: "Added batch 1/2 to 456\nRemoved batch 1/1 from 456\n",
+		},
+		{
+			name: "long name",
+			events: []spotify.ProgressEvent{
+				{Stage: spotify.StageFetch, Playlist: spotify.Playlist{Name: strings.Repeat("a", 40)}, Done: 1, Total: 1},
+			},
+			expected: "Fetched " + strings.Repeat("a", 29) + "…: 1 tracks\n",
+		},
+		{
+			name: "retry",
+			events: []spotify.ProgressEvent{
+				{Stage: spotify.StageRetry, Attempt: 2, Delay: 1520 * time.Millisecond, Err: errors.New("rate limited")},
+			},
+			expected: "Retrying in 1.5s (attempt 2 of 5): rate limited\n",
+		},
+	}
+	cli.MaxAttempts = 5
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			d := &progressDisplay{w: &buf}
+			for _, event := range test.events {
+				d.report(event)
+			}
+			assert.Equal(t, test.expected, buf.String())
+		})
+	}
+
+	t.Run("status line on a terminal", func(t *testing.T) {
+		var buf bytes.Buffer
+		d := &progressDisplay{w: &buf, tty: true}
+		d.report(spotify.ProgressEvent{Stage: spotify.StageFetch, Playlist: playlist, Pages: 1, Done: 150, Total: 100})
+		assert.True(t, strings.HasPrefix(buf.String(), "\r\x1b[2KFetching Playlist 1 "))
+		assert.True(t, strings.HasSuffix(buf.String(), " 150/100 tracks, 1 pages · 150 collected"))
+		buf.Reset()
+		_, err := d.Write([]byte("done\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, "\r\x1b[2Kdone\n", buf.String(), "expected the status line to be cleared")
+	})
+}
@@ -0,0 +1,62 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvery(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, now.Add(6*time.Hour), Every(6*time.Hour).Next(now))
+}
+
+func TestParseCron(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		now := time.Date(2024, 1, 1, 12, 30, 15, 0, time.UTC) // Monday
+		tests := []struct {
+			expr     string
+			expected time.Time
+		}{
+			{"* * * * *", time.Date(2024, 1, 1, 12, 31, 0, 0, time.UTC)},
+			{"0 */6 * * *", time.Date(2024, 1, 1, 18, 0, 0, 0, time.UTC)},
+			{"15,45 9-17 * * *", time.Date(2024, 1, 1, 12, 45, 0, 0, time.UTC)},
+			{"0 0 1 * *", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+			{"0 9 * * 0", time.Date(2024, 1, 7, 9, 0, 0, 0, time.UTC)},
+			{"0 9 * * 7", time.Date(2024, 1, 7, 9, 0, 0, 0, time.UTC)},
+			{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+			// Either the day of month or the day of week matches.
+			{"0 0 15 * 3", time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+		}
+		for _, test := range tests {
+			c, err := ParseCron(test.expr)
+			assert.NoError(t, err, test.expr)
+			assert.Equal(t, test.expected, c.Next(now), test.expr)
+		}
+	})
+
+	t.Run("half-hour offsets", func(t *testing.T) {
+		for _, name := range []string{"Asia/Kolkata", "America/St_Johns", "Australia/Adelaide"} {
+			loc, err := time.LoadLocation(name)
+			assert.NoError(t, err, name)
+			now := time.Date(2024, 1, 1, 12, 30, 15, 0, loc)
+			c, err := ParseCron("0 */6 * * *")
+			assert.NoError(t, err)
+			assert.Equal(t, time.Date(2024, 1, 1, 18, 0, 0, 0, loc), c.Next(now), name)
+		}
+	})
+
+	t.Run("invalid expressions", func(t *testing.T) {
+		for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+			_, err := ParseCron(expr)
+			assert.Error(t, err, expr)
+		}
+	})
+
+	t.Run("never matches", func(t *testing.T) {
+		c, err := ParseCron("0 0 31 2 *")
+		assert.NoError(t, err)
+		assert.True(t, c.Next(time.Now()).IsZero())
+	})
+}
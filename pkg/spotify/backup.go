@@ -0,0 +1,93 @@
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// Backup is a snapshot of a playlist's metadata and ordered items.
+type Backup struct {
+	CreatedAt time.Time       `json:"created_at"`
+	Playlist  Playlist        `json:"playlist"`
+	Items     []PlaylistTrack `json:"items"`
+}
+
+// BackupPlaylist fetches the metadata and items of a playlist.
+func (s *Spotify) BackupPlaylist(playlistID string) (*Backup, error) {
+	return s.BackupPlaylistContext(context.Background(), playlistID)
+}
+
+// BackupPlaylistContext is like BackupPlaylist but includes a context.
+func (s *Spotify) BackupPlaylistContext(ctx context.Context, playlistID string) (*Backup, error) {
+	playlist, err := s.getPlaylist(ctx, playlistID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.getTracksFromPlaylist(ctx, *playlist)
+	if err != nil {
+		return nil, err
+	}
+	return &Backup{
+		CreatedAt: time.Now().UTC(),
+		Playlist:  *playlist,
+		Items:     items,
+	}, nil
+}
+
+// Characters that are not safe in file names on every platform.
+var unsafeFileChars = regexp.MustCompile(`[^0-9A-Za-z._-]+`)
+
+/*
+WriteBackup writes the backup to a JSON file in dir, named after the
+playlist's name and ID, and returns the path of the file.
+*/
+func WriteBackup(dir string, backup *Backup) (string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	name := unsafeFileChars.ReplaceAllString(backup.Playlist.Name, "_")
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.json", name, backup.Playlist.ID))
+	data, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write backup: %w", err)
+	}
+	return path, nil
+}
+
+// ReadBackup reads a backup written by WriteBackup.
+func ReadBackup(path string) (*Backup, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+	var backup Backup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal backup: %w", err)
+	}
+	return &backup, nil
+}
+
+/*
+GetSnapshotID retrieves the current snapshot ID of a playlist,
+which differs from a backup's if the playlist changed since.
+*/
+func (s *Spotify) GetSnapshotID(playlistID string) (string, error) {
+	return s.GetSnapshotIDContext(context.Background(), playlistID)
+}
+
+// GetSnapshotIDContext is like GetSnapshotID but includes a context.
+func (s *Spotify) GetSnapshotIDContext(ctx context.Context, playlistID string) (string, error) {
+	playlist, err := s.getPlaylist(ctx, playlistID)
+	if err != nil {
+		return "", err
+	}
+	return playlist.SnapshotID, nil
+}
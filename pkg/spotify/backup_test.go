@@ -0,0 +1,47 @@
+package spotify
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupPlaylist(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		mockClient := &http.Client{
+			Transport: &mockRoundTripper{
+				roundTripFunc: func(req *http.Request) (*http.Response, error) {
+					body := `{"id": "123", "name": "Road Trip", "snapshot_id": "snap1"}`
+					if req.URL.Path == "/playlists/123/tracks" {
+						body = `{"items": [{"track": {"uri": "1"}}, {"track": {"uri": "2"}}, {"track": {"uri": "1"}}], "next": null}`
+					}
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(strings.NewReader(body)),
+					}, nil
+				},
+			},
+		}
+		s := Spotify{
+			Client: mockClient,
+			Token:  "mockToken",
+		}
+		backup, err := s.BackupPlaylist("123")
+		assert.NoError(t, err)
+		assert.Equal(t, Playlist{ID: "123", Name: "Road Trip", SnapshotID: "snap1"}, backup.Playlist)
+		assert.Equal(t, []string{"1", "2", "1"}, TrackURIs(backup.Items), "backup should keep duplicates and order")
+
+		path, err := WriteBackup(t.TempDir(), backup)
+		assert.NoError(t, err)
+		assert.Equal(t, "Road_Trip-123.json", filepath.Base(path))
+		restored, err := ReadBackup(path)
+		assert.NoError(t, err)
+		assert.Equal(t, backup.Playlist, restored.Playlist)
+		assert.Equal(t, backup.Items, restored.Items)
+		assert.True(t, backup.CreatedAt.Equal(restored.CreatedAt))
+	})
+}
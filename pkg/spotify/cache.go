@@ -0,0 +1,92 @@
+package spotify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+/*
+Cache stores the items of playlists on disk, one file per playlist,
+valid for as long as the playlist's snapshot ID does not change.
+*/
+type Cache struct {
+	Dir string
+}
+
+/*
+cacheVersion changes whenever the fields fetched for playlist items
+change, so entries cached without them are fetched again.
+*/
+const cacheVersion = 1
+
+type cacheEntry struct {
+	Version    int             `json:"version"`
+	SnapshotID string          `json:"snapshot_id"`
+	Items      []PlaylistTrack `json:"items"`
+}
+
+func (c *Cache) path(playlistID string) string {
+	name := unsafeFileChars.ReplaceAllString(playlistID, "_")
+	return filepath.Join(c.Dir, name+".json")
+}
+
+/*
+Get returns the cached items of the playlist if they were cached at
+the snapshot. Unreadable cache files are treated as missing.
+*/
+func (c *Cache) Get(playlistID string, snapshotID string) ([]PlaylistTrack, bool) {
+	data, err := os.ReadFile(c.path(playlistID))
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Version != cacheVersion || entry.SnapshotID != snapshotID {
+		return nil, false
+	}
+	return entry.Items, true
+}
+
+// Put caches the items of the playlist at the snapshot, replacing older snapshots.
+func (c *Cache) Put(playlistID string, snapshotID string, items []PlaylistTrack) error {
+	if err := os.MkdirAll(c.Dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	data, err := json.Marshal(cacheEntry{Version: cacheVersion, SnapshotID: snapshotID, Items: items})
+	if err != nil {
+		return err
+	}
+	if err := writeFile(c.path(playlistID), data); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	return nil
+}
+
+/*
+writeFile writes data to a temporary file next to path first,
+so an interrupted run never leaves a partially written file.
+*/
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Clear removes every cached playlist.
+func (c *Cache) Clear() error {
+	if err := os.RemoveAll(c.Dir); err != nil {
+		return fmt.Errorf("failed to clear cache: %w", err)
+	}
+	return nil
+}
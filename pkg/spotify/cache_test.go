@@ -0,0 +1,89 @@
+package spotify
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		c := Cache{Dir: filepath.Join(t.TempDir(), "cache")}
+		items := tracksFromURIs("1", "2")
+		assert.NoError(t, c.Put("p1", "snap1", items))
+
+		cached, ok := c.Get("p1", "snap1")
+		assert.True(t, ok)
+		assert.Equal(t, TrackURIs(items), TrackURIs(cached))
+
+		_, ok = c.Get("p1", "snap2")
+		assert.False(t, ok, "expected a miss for a newer snapshot")
+		_, ok = c.Get("p2", "snap1")
+		assert.False(t, ok, "expected a miss for an uncached playlist")
+
+		assert.NoError(t, c.Clear())
+		_, ok = c.Get("p1", "snap1")
+		assert.False(t, ok, "expected a miss after clearing the cache")
+	})
+
+	t.Run("corrupt entry", func(t *testing.T) {
+		c := Cache{Dir: t.TempDir()}
+		assert.NoError(t, os.WriteFile(c.path("p1"), []byte("{"), 0o600))
+		_, ok := c.Get("p1", "snap1")
+		assert.False(t, ok)
+	})
+
+	t.Run("outdated entry", func(t *testing.T) {
+		c := Cache{Dir: t.TempDir()}
+		entry := `{"snapshot_id": "snap1", "items": [{"track": {"uri": "1"}}]}`
+		assert.NoError(t, os.WriteFile(c.path("p1"), []byte(entry), 0o600))
+		_, ok := c.Get("p1", "snap1")
+		assert.False(t, ok, "expected a miss for an entry from an older version")
+	})
+}
+
+func TestGetTracks(t *testing.T) {
+	t.Run("serves unchanged playlists from the cache", func(t *testing.T) {
+		var requests []string
+		mockClient := &http.Client{
+			Transport: &mockRoundTripper{
+				roundTripFunc: func(req *http.Request) (*http.Response, error) {
+					requests = append(requests, req.URL.Path)
+					mockResponse := `{"items": [{"track": {"uri": "spotify:track:1"}}], "next": null}`
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(strings.NewReader(mockResponse)),
+					}, nil
+				},
+			},
+		}
+		s := Spotify{
+			Client: mockClient,
+			Token:  "mockToken",
+			Cache:  &Cache{Dir: t.TempDir()},
+		}
+		playlists := []Playlist{{ID: "p1", SnapshotID: "snap1"}, {ID: "p2"}}
+
+		_, err := s.GetTracks(playlists)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"/playlists/p1/tracks", "/playlists/p2/tracks"}, requests)
+
+		// p2 has no known snapshot, so it is always fetched.
+		requests = nil
+		sources, err := s.GetTracks(playlists)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"/playlists/p2/tracks"}, requests)
+		assert.Equal(t, []string{"spotify:track:1"}, TrackURIs(sources[0]))
+
+		requests = nil
+		playlists[0].SnapshotID = "snap2"
+		_, err = s.GetTracks(playlists)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"/playlists/p1/tracks", "/playlists/p2/tracks"}, requests)
+	})
+}
@@ -2,10 +2,15 @@ package spotify
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"sort"
 	"strings"
 	"time"
 )
@@ -14,6 +19,12 @@ type Spotify struct {
 	Token  string
 	Client *http.Client
 	UserID string
+	// Cache, if set, serves the items of playlists that did not change.
+	Cache *Cache
+	// Progress, if set, is called as pages are fetched and batches are sent.
+	Progress func(ProgressEvent)
+	// Retry, if set, sends requests that failed again.
+	Retry *RetryPolicy
 }
 
 type Profile struct {
@@ -21,8 +32,78 @@ type Profile struct {
 }
 
 type Playlist struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// SnapshotID changes every time the playlist is modified.
+	SnapshotID string `json:"snapshot_id,omitempty"`
+	// Tracks is only set for playlists listed by GetPlaylists.
+	Tracks *PlaylistTracksRef `json:"tracks,omitempty"`
+}
+
+// PlaylistTracksRef is the number of items in a playlist.
+type PlaylistTracksRef struct {
+	Total int `json:"total"`
+}
+
+type MissingPlaylist struct {
+	Name        string   `json:"name"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+type AmbiguousPlaylist struct {
+	Name string   `json:"name"`
+	IDs  []string `json:"ids"`
+}
+
+// AmbiguousPlaylistError is returned if a name matches more than one playlist.
+type AmbiguousPlaylistError struct {
+	AmbiguousPlaylist
+}
+
+func (e *AmbiguousPlaylistError) Error() string {
+	return fmt.Sprintf(
+		"playlist name %q matches %d playlists (%s), use an ID instead",
+		e.Name,
+		len(e.IDs),
+		strings.Join(e.IDs, ", "),
+	)
+}
+
+type PlaylistMatches struct {
+	Matched   []Playlist
+	Missing   []MissingPlaylist
+	Ambiguous []AmbiguousPlaylist
+}
+
+// IDs returns the IDs of the matched playlists in config order.
+func (m *PlaylistMatches) IDs() []string {
+	var ids []string
+	for _, playlist := range m.Matched {
+		ids = append(ids, playlist.ID)
+	}
+	return ids
+}
+
+/*
+Snapshots returns the snapshot ID of each matched playlist by ID, or
+the modification time of the file for file sources. Comparing them
+across runs tells if any source changed.
+*/
+func (m *PlaylistMatches) Snapshots() (map[string]string, error) {
+	snapshots := make(map[string]string)
+	for _, playlist := range m.Matched {
+		if path, ok := strings.CutPrefix(playlist.ID, fileSourcePrefix); ok {
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil, err
+			}
+			snapshots[playlist.ID] = info.ModTime().UTC().Format(time.RFC3339Nano)
+			continue
+		}
+		snapshots[playlist.ID] = playlist.SnapshotID
+	}
+	return snapshots, nil
 }
 
 type PlaylistsResponse struct {
@@ -30,23 +111,79 @@ type PlaylistsResponse struct {
 	Next  *string    `json:"next"`
 }
 
+type User struct {
+	ID string `json:"id"`
+}
+
+type Artist struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type Album struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	ReleaseDate string `json:"release_date"`
+}
+
+type ExternalIDs struct {
+	ISRC string `json:"isrc"`
+}
+
 type Track struct {
+	// Type is "track" or "episode".
+	Type        string      `json:"type"`
+	URI         string      `json:"uri"`
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Artists     []Artist    `json:"artists"`
+	Album       Album       `json:"album"`
+	DurationMS  int         `json:"duration_ms"`
+	Explicit    bool        `json:"explicit"`
+	Popularity  int         `json:"popularity"`
+	ExternalIDs ExternalIDs `json:"external_ids"`
+	IsLocal     bool        `json:"is_local"`
+	// IsPlayable is nil when Spotify does not report playability.
+	IsPlayable *bool `json:"is_playable"`
+	// LinkedFrom is set when Spotify relinked the track for the user's market.
+	LinkedFrom *TrackRef `json:"linked_from,omitempty"`
+}
+
+type TrackRef struct {
 	URI string `json:"uri"`
 }
 
 type PlaylistTrack struct {
-	Track Track `json:"track"`
+	AddedAt time.Time `json:"added_at"`
+	AddedBy User      `json:"added_by"`
+	Track   Track     `json:"track"`
 }
 
 type PlaylistItemsResponse struct {
 	Items []PlaylistTrack `json:"items"`
 	Next  *string         `json:"next"`
+	Total int             `json:"total"`
+}
+
+type SearchResponse struct {
+	Tracks struct {
+		Items []Track `json:"items"`
+	} `json:"tracks"`
+}
+
+// TracksResponse lists tracks looked up by ID, with null for unknown IDs.
+type TracksResponse struct {
+	Tracks []*Track `json:"tracks"`
 }
 
 type AddTracksToPlaylistResponse struct {
 	SnapshotID string `json:"snapshot_id"`
 }
 
+type RemoveTracksFromPlaylistResponse struct {
+	SnapshotID string `json:"snapshot_id"`
+}
+
 type CreatePlaylistResponse struct {
 	ID string `json:"id"`
 }
@@ -54,7 +191,31 @@ type CreatePlaylistResponse struct {
 const API = "https://api.spotify.com/v1"
 const PROXY = "http://localhost:3000"
 
+// RequestError is returned when Spotify responds with an unexpected status code.
+type RequestError struct {
+	StatusCode int
+	// RetryAfter is how long Spotify asked to wait before trying again, if it did.
+	RetryAfter time.Duration
+}
+
+func (e *RequestError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("request failed with status code %d, retry after %s", e.StatusCode, e.RetryAfter)
+	}
+	return fmt.Sprintf("request failed with status code %d", e.StatusCode)
+}
+
+// IsNotFound reports whether err is a RequestError for a missing or invalid resource.
+func IsNotFound(err error) bool {
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.StatusCode == http.StatusNotFound || reqErr.StatusCode == http.StatusBadRequest
+	}
+	return false
+}
+
 func (s *Spotify) handleRequest(
+	ctx context.Context,
 	api,
 	method,
 	endpoint string,
@@ -65,20 +226,70 @@ func (s *Spotify) handleRequest(
 	}
 	url := api + endpoint
 	// fmt.Println(url)
-	req, err := http.NewRequest(method, url, body)
+	// Reads the body up front, so it can be sent again.
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+	var waited time.Duration
+	for attempt := 1; ; attempt++ {
+		respBody, err := s.sendRequest(ctx, method, url, data)
+		if err == nil || s.Retry == nil || ctx.Err() != nil {
+			return respBody, err
+		}
+		delay, ok := s.Retry.delay(method, attempt, waited, err)
+		if !ok {
+			return nil, err
+		}
+		s.progress(ProgressEvent{
+			Stage:   StageRetry,
+			Attempt: attempt + 1,
+			Delay:   delay,
+			Err:     err,
+		})
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		waited += delay
+	}
+}
+
+// sendRequest sends a request once.
+func (s *Spotify) sendRequest(ctx context.Context, method, url string, data []byte) ([]byte, error) {
+	var body io.Reader
+	if data != nil {
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	resp, err := s.Client.Do(req)
 	if err != nil {
+		// Leaves out the URL of requests that were canceled.
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
-	if method == "GET" && resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("request failed with status code %d", resp.StatusCode)
+	expected := http.StatusOK
+	if method == "POST" {
+		expected = http.StatusCreated
 	}
-	if method == "POST" && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("request failed with status code %d", resp.StatusCode)
+	if resp.StatusCode != expected {
+		return nil, &RequestError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
 	}
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -87,8 +298,8 @@ func (s *Spotify) handleRequest(
 	return respBody, nil
 }
 
-func (s *Spotify) getProfile() (*Profile, error) {
-	body, err := s.handleRequest(PROXY, "GET", "/me", nil)
+func (s *Spotify) getProfile(ctx context.Context) (*Profile, error) {
+	body, err := s.handleRequest(ctx, PROXY, "GET", "/me", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -100,14 +311,19 @@ func (s *Spotify) getProfile() (*Profile, error) {
 }
 
 func (s *Spotify) GetUserID() (string, error) {
-	profile, err := s.getProfile()
+	return s.GetUserIDContext(context.Background())
+}
+
+// GetUserIDContext is like GetUserID but includes a context.
+func (s *Spotify) GetUserIDContext(ctx context.Context) (string, error) {
+	profile, err := s.getProfile(ctx)
 	if err != nil {
 		return "", err
 	}
 	return profile.ID, nil
 }
 
-func (s *Spotify) getPlaylists(userID string) ([]Playlist, error) {
+func (s *Spotify) getPlaylists(ctx context.Context, userID string) ([]Playlist, error) {
 	var allPlaylists []Playlist
 	endpoint := fmt.Sprintf("/users/%s/playlists", userID)
 	/*
@@ -115,7 +331,7 @@ func (s *Spotify) getPlaylists(userID string) ([]Playlist, error) {
 		to add logic to be able to retrieve playlists in multiple cycles.
 	*/
 	for {
-		body, err := s.handleRequest(PROXY, "GET", endpoint, nil)
+		body, err := s.handleRequest(ctx, PROXY, "GET", endpoint, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -133,61 +349,311 @@ func (s *Spotify) getPlaylists(userID string) ([]Playlist, error) {
 	return allPlaylists, nil
 }
 
+func (s *Spotify) getPlaylist(ctx context.Context, playlistID string) (*Playlist, error) {
+	endpoint := fmt.Sprintf("/playlists/%s?fields=id,name,description,snapshot_id", playlistID)
+	body, err := s.handleRequest(ctx, PROXY, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	var playlist Playlist
+	if err := json.Unmarshal(body, &playlist); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal playlist: %w", err)
+	}
+	return &playlist, nil
+}
+
+// GetPlaylists retrieves every playlist in the user's library.
+func (s *Spotify) GetPlaylists(userID string) ([]Playlist, error) {
+	return s.GetPlaylistsContext(context.Background(), userID)
+}
+
+// GetPlaylistsContext is like GetPlaylists but includes a context.
+func (s *Spotify) GetPlaylistsContext(ctx context.Context, userID string) ([]Playlist, error) {
+	return s.getPlaylists(ctx, userID)
+}
+
 /*
 GetPlaylistIDsByName retrieves the IDs corresponding
 to the playlists provided in the user's ~/.mergify/config.json file.
+
+Entries that are not the name of one of the user's playlists may be
+a playlist URL, URI or ID, including playlists owned by other users.
+File entries are matched to an ID that GetPlaylistTracks reads from.
+Pattern entries are expanded against the user's playlists, skipping
+playlists that match exclude or were already matched.
+
+Names that match more than one playlist are reported as ambiguous
+and matched to their first candidate. Entries that do not match any
+playlist are reported as missing, along with similar playlist names.
 */
-func (s *Spotify) GetPlaylistIDsByName(userID string, cfgPlaylists []string) ([]string, error) {
-	playlists, err := s.getPlaylists(userID)
+func (s *Spotify) GetPlaylistIDsByName(
+	userID string,
+	cfgPlaylists []Source,
+	exclude []Source,
+) (*PlaylistMatches, error) {
+	return s.GetPlaylistIDsByNameContext(context.Background(), userID, cfgPlaylists, exclude)
+}
+
+// GetPlaylistIDsByNameContext is like GetPlaylistIDsByName but includes a context.
+func (s *Spotify) GetPlaylistIDsByNameContext(
+	ctx context.Context,
+	userID string,
+	cfgPlaylists []Source,
+	exclude []Source,
+) (*PlaylistMatches, error) {
+	playlists, err := s.getPlaylists(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
-	hashMap := make(map[string]string)
+	return s.MatchPlaylistsContext(ctx, playlists, cfgPlaylists, exclude)
+}
+
+/*
+MatchPlaylists is like GetPlaylistIDsByName but matches the entries
+against playlists already listed with GetPlaylists.
+*/
+func (s *Spotify) MatchPlaylists(
+	playlists []Playlist,
+	cfgPlaylists []Source,
+	exclude []Source,
+) (*PlaylistMatches, error) {
+	return s.MatchPlaylistsContext(context.Background(), playlists, cfgPlaylists, exclude)
+}
+
+// MatchPlaylistsContext is like MatchPlaylists but includes a context.
+func (s *Spotify) MatchPlaylistsContext(
+	ctx context.Context,
+	playlists []Playlist,
+	cfgPlaylists []Source,
+	exclude []Source,
+) (*PlaylistMatches, error) {
+	hashMap := make(map[string][]Playlist)
+	var names []string
 	for _, playlist := range playlists {
-		hashMap[playlist.Name] = playlist.ID
+		if _, exists := hashMap[playlist.Name]; !exists {
+			names = append(names, playlist.Name)
+		}
+		hashMap[playlist.Name] = append(hashMap[playlist.Name], playlist)
 	}
-	var result []string
-	for _, name := range cfgPlaylists {
-		if id, exists := hashMap[name]; exists {
-			result = append(result, id)
+	result := &PlaylistMatches{}
+	seen := make(map[string]bool)
+	for _, source := range cfgPlaylists {
+		if source.File != "" {
+			result.Matched = append(result.Matched, Playlist{
+				ID:   FileSourceID(source.File),
+				Name: source.File,
+			})
+			continue
+		}
+		if source.IsPattern() {
+			expanded, err := expand(playlists, source, exclude)
+			if err != nil {
+				return nil, err
+			}
+			if len(expanded) == 0 {
+				result.Missing = append(result.Missing, MissingPlaylist{Name: source.String()})
+			}
+			for _, playlist := range expanded {
+				if !seen[playlist.ID] {
+					result.Matched = append(result.Matched, playlist)
+					seen[playlist.ID] = true
+				}
+			}
+			continue
+		}
+		name := source.Name
+		matches, exists := hashMap[name]
+		if !exists {
+			if id, ok := ParsePlaylistID(name); ok {
+				// Each playlist is only merged once, however it is listed.
+				if seen[id] {
+					continue
+				}
+				playlist, err := s.getPlaylist(ctx, id)
+				if err == nil {
+					if !seen[playlist.ID] {
+						result.Matched = append(result.Matched, *playlist)
+						seen[playlist.ID] = true
+					}
+					continue
+				}
+				if !IsNotFound(err) {
+					return nil, err
+				}
+			}
+			result.Missing = append(result.Missing, MissingPlaylist{
+				Name:        name,
+				Suggestions: Suggest(name, names),
+			})
+			continue
+		}
+		if len(matches) > 1 {
+			var ids []string
+			for _, playlist := range matches {
+				ids = append(ids, playlist.ID)
+			}
+			result.Ambiguous = append(result.Ambiguous, AmbiguousPlaylist{
+				Name: name,
+				IDs:  ids,
+			})
+		}
+		if !seen[matches[0].ID] {
+			result.Matched = append(result.Matched, matches[0])
+			seen[matches[0].ID] = true
 		}
 	}
 	return result, nil
 }
 
+/*
+GetPlaylistID retrieves the ID of the user's playlist whose name,
+URL, URI or ID matches target, e.g. the sync target from
+~/.mergify/config.json. It returns an AmbiguousPlaylistError if
+several playlists have the name.
+*/
+func (s *Spotify) GetPlaylistID(userID, target string) (string, error) {
+	return s.GetPlaylistIDContext(context.Background(), userID, target)
+}
+
+// GetPlaylistIDContext is like GetPlaylistID but includes a context.
+func (s *Spotify) GetPlaylistIDContext(ctx context.Context, userID, target string) (string, error) {
+	playlists, err := s.getPlaylists(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	id, _ := ParsePlaylistID(target)
+	var ids []string
+	for _, playlist := range playlists {
+		if playlist.ID == target || playlist.ID == id {
+			return playlist.ID, nil
+		}
+		if playlist.Name == target {
+			ids = append(ids, playlist.ID)
+		}
+	}
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("playlist not found: %s", target)
+	case 1:
+		return ids[0], nil
+	default:
+		return "", &AmbiguousPlaylistError{AmbiguousPlaylist{Name: target, IDs: ids}}
+	}
+}
+
 func (s *Spotify) GetPlaylistTrackIDs(playlistIDs []string) ([]string, error) {
-	var trackURIs []string
-	hashMap := make(map[string]bool)
+	return s.GetPlaylistTrackIDsContext(context.Background(), playlistIDs)
+}
+
+// GetPlaylistTrackIDsContext is like GetPlaylistTrackIDs but includes a context.
+func (s *Spotify) GetPlaylistTrackIDsContext(ctx context.Context, playlistIDs []string) ([]string, error) {
+	sources, err := s.GetPlaylistTracksContext(ctx, playlistIDs)
+	if err != nil {
+		return nil, err
+	}
+	sources, _ = SkipUnmergeable(sources, true)
+	/*
+		Omits duplicate Track IDs
+		to prevent the created playlist
+		from having duplicate tracks.
+	*/
+	return PlaylistURIs(Union(sources)), nil
+}
+
+/*
+GetPlaylistTracks retrieves the tracks of each playlist,
+keeping them grouped by playlist in the order provided.
+IDs returned by FileSourceID are read from their file.
+*/
+func (s *Spotify) GetPlaylistTracks(playlistIDs []string) ([][]PlaylistTrack, error) {
+	return s.GetPlaylistTracksContext(context.Background(), playlistIDs)
+}
+
+// GetPlaylistTracksContext is like GetPlaylistTracks but includes a context.
+func (s *Spotify) GetPlaylistTracksContext(
+	ctx context.Context,
+	playlistIDs []string,
+) ([][]PlaylistTrack, error) {
+	var playlists []Playlist
 	for _, id := range playlistIDs {
-		playlistTracks, err := s.getTracksFromPlaylist(id)
+		playlists = append(playlists, Playlist{ID: id})
+	}
+	return s.GetTracksContext(ctx, playlists)
+}
+
+/*
+GetTracks is like GetPlaylistTracks, but serves playlists whose
+snapshot ID is known from s.Cache if they did not change since
+they were cached.
+*/
+func (s *Spotify) GetTracks(playlists []Playlist) ([][]PlaylistTrack, error) {
+	return s.GetTracksContext(context.Background(), playlists)
+}
+
+// GetTracksContext is like GetTracks but includes a context.
+func (s *Spotify) GetTracksContext(ctx context.Context, playlists []Playlist) ([][]PlaylistTrack, error) {
+	var sources [][]PlaylistTrack
+	for _, playlist := range playlists {
+		var playlistTracks []PlaylistTrack
+		var err error
+		if path, ok := strings.CutPrefix(playlist.ID, fileSourcePrefix); ok {
+			playlistTracks, err = s.getTracksFromFile(ctx, path)
+		} else {
+			playlistTracks, err = s.getCachedTracks(ctx, playlist)
+		}
 		if err != nil {
 			return nil, err
 		}
-		/*
-			Omits duplicate Track IDs
-			to prevent the created playlist
-			from having duplicate tracks.
-		*/
-		for _, p := range playlistTracks {
-			if !hashMap[p.Track.URI] {
-				trackURIs = append(trackURIs, p.Track.URI)
-				hashMap[p.Track.URI] = true
-			}
-		}
+		sources = append(sources, playlistTracks)
+	}
+	return sources, nil
+}
+
+func (s *Spotify) getCachedTracks(ctx context.Context, playlist Playlist) ([]PlaylistTrack, error) {
+	if s.Cache == nil || playlist.SnapshotID == "" {
+		return s.getTracksFromPlaylist(ctx, playlist)
+	}
+	if items, ok := s.Cache.Get(playlist.ID, playlist.SnapshotID); ok {
+		s.progress(ProgressEvent{
+			Stage:    StageFetch,
+			Playlist: playlist,
+			Done:     len(items),
+			Total:    len(items),
+			Cached:   true,
+		})
+		return items, nil
+	}
+	items, err := s.getTracksFromPlaylist(ctx, playlist)
+	if err != nil {
+		return nil, err
 	}
-	return trackURIs, nil
+	if err := s.Cache.Put(playlist.ID, playlist.SnapshotID, items); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-func (s *Spotify) getTracksFromPlaylist(playlistID string) ([]PlaylistTrack, error) {
+/*
+Limits the playlist items response to the fields of PlaylistTrack.
+market=from_token makes Spotify report whether each track is playable
+and additional_types=episode returns podcast episodes as episodes.
+*/
+const playlistItemsQuery = "fields=next,total,items(added_at,added_by.id,track(" +
+	"type,uri,id,name,artists(id,name),album(id,name,release_date),duration_ms," +
+	"explicit,popularity,external_ids.isrc,is_local,is_playable,linked_from.uri))" +
+	"&market=from_token&additional_types=episode"
+
+func (s *Spotify) getTracksFromPlaylist(ctx context.Context, playlist Playlist) ([]PlaylistTrack, error) {
 	var allPlaylistTracks []PlaylistTrack
-	endpoint := fmt.Sprintf("/playlists/%s/tracks", playlistID)
+	endpoint := fmt.Sprintf("/playlists/%s/tracks?%s", playlist.ID, playlistItemsQuery)
+	pages := 0
 	/*
 		Spotify defaults to returning 20 tracks
 		per request, so we need to implement track retrieval mechanism
 		that can handle playlists with more than 20 tracks.
 	*/
 	for {
-		body, err := s.handleRequest(PROXY, "GET", endpoint, nil)
+		body, err := s.handleRequest(ctx, PROXY, "GET", endpoint, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -196,6 +662,19 @@ func (s *Spotify) getTracksFromPlaylist(playlistID string) ([]PlaylistTrack, err
 			return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 		}
 		allPlaylistTracks = append(allPlaylistTracks, response.Items...)
+		pages++
+		total := response.Total
+		if response.Next == nil {
+			// Keeps Done and Total equal for the last page even if total was not sent.
+			total = len(allPlaylistTracks)
+		}
+		s.progress(ProgressEvent{
+			Stage:    StageFetch,
+			Playlist: playlist,
+			Pages:    pages,
+			Done:     len(allPlaylistTracks),
+			Total:    total,
+		})
 		if response.Next == nil {
 			break
 		}
@@ -205,7 +684,104 @@ func (s *Spotify) getTracksFromPlaylist(playlistID string) ([]PlaylistTrack, err
 	return allPlaylistTracks, nil
 }
 
+/*
+SearchTrack returns the best match for a track by title and artist,
+or nil if Spotify finds none. artist may be empty.
+*/
+func (s *Spotify) SearchTrack(artist, title string) (*Track, error) {
+	return s.SearchTrackContext(context.Background(), artist, title)
+}
+
+// SearchTrackContext is like SearchTrack but includes a context.
+func (s *Spotify) SearchTrackContext(ctx context.Context, artist, title string) (*Track, error) {
+	query := fmt.Sprintf("track:%s", title)
+	if artist != "" {
+		query += fmt.Sprintf(" artist:%s", artist)
+	}
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("type", "track")
+	params.Set("limit", "1")
+	params.Set("market", "from_token")
+	body, err := s.handleRequest(ctx, PROXY, "GET", "/search?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var response SearchResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if len(response.Tracks.Items) == 0 {
+		return nil, nil
+	}
+	return &response.Tracks.Items[0], nil
+}
+
+// Spotify looks up at most 50 tracks per request.
+const lookupBatchSize = 50
+
+/*
+lookupTracks fetches the tracks with the given URIs, in batches.
+Tracks that Spotify does not know are nil.
+*/
+func (s *Spotify) lookupTracks(ctx context.Context, uris []string) ([]*Track, error) {
+	var tracks []*Track
+	for start := 0; start < len(uris); start += lookupBatchSize {
+		end := min(start+lookupBatchSize, len(uris))
+		var ids []string
+		for _, uri := range uris[start:end] {
+			ids = append(ids, strings.TrimPrefix(uri, "spotify:track:"))
+		}
+		params := url.Values{}
+		params.Set("ids", strings.Join(ids, ","))
+		params.Set("market", "from_token")
+		body, err := s.handleRequest(ctx, PROXY, "GET", "/tracks?"+params.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		var response TracksResponse
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal tracks: %w", err)
+		}
+		if len(response.Tracks) != len(ids) {
+			return nil, fmt.Errorf("expected %d tracks, got %d", len(ids), len(response.Tracks))
+		}
+		tracks = append(tracks, response.Tracks...)
+	}
+	return tracks, nil
+}
+
+// PlaylistName returns the name given to new playlists.
+func PlaylistName() string {
+	now := time.Now()
+	millis := now.UnixNano() / 1e6
+	return fmt.Sprintf("Mergify Playlist %d", millis)
+}
+
 func (s *Spotify) CreatePlaylist(userID string, trackIDs []string) (string, error) {
+	return s.CreatePlaylistContext(context.Background(), userID, trackIDs)
+}
+
+// CreatePlaylistContext is like CreatePlaylist but includes a context.
+func (s *Spotify) CreatePlaylistContext(
+	ctx context.Context,
+	userID string,
+	trackIDs []string,
+) (string, error) {
+	return s.CreatePlaylistWithNameContext(ctx, userID, PlaylistName(), trackIDs)
+}
+
+func (s *Spotify) CreatePlaylistWithName(userID, name string, trackIDs []string) (string, error) {
+	return s.CreatePlaylistWithNameContext(context.Background(), userID, name, trackIDs)
+}
+
+// CreatePlaylistWithNameContext is like CreatePlaylistWithName but includes a context.
+func (s *Spotify) CreatePlaylistWithNameContext(
+	ctx context.Context,
+	userID,
+	name string,
+	trackIDs []string,
+) (string, error) {
 	if len(trackIDs) == 0 {
 		/*
 			Exit if no tracks found in playlists
@@ -213,19 +789,39 @@ func (s *Spotify) CreatePlaylist(userID string, trackIDs []string) (string, erro
 		*/
 		return "", fmt.Errorf("no tracks found")
 	}
-	now := time.Now()
-	millis := now.UnixNano() / 1e6
-	name := fmt.Sprintf("Mergify Playlist %d", millis)
+	return s.CreatePlaylistWithDescriptionContext(
+		ctx,
+		userID,
+		name,
+		"Created with https://github.com/mhborthwick/mergify",
+	)
+}
+
+/*
+CreatePlaylistWithDescription creates an empty playlist, e.g. to
+restore a backed up playlist with its name and description.
+*/
+func (s *Spotify) CreatePlaylistWithDescription(userID, name, description string) (string, error) {
+	return s.CreatePlaylistWithDescriptionContext(context.Background(), userID, name, description)
+}
+
+// CreatePlaylistWithDescriptionContext is like CreatePlaylistWithDescription but includes a context.
+func (s *Spotify) CreatePlaylistWithDescriptionContext(
+	ctx context.Context,
+	userID,
+	name,
+	description string,
+) (string, error) {
 	requestBody := map[string]string{
 		"name":        name,
-		"description": "Created with https://github.com/mhborthwick/mergify",
+		"description": description,
 	}
 	jsonRequestBody, err := json.Marshal(requestBody)
 	if err != nil {
 		return "", err
 	}
 	endpoint := fmt.Sprintf("/users/%s/playlists", userID)
-	body, err := s.handleRequest(PROXY, "POST", endpoint, bytes.NewBuffer(jsonRequestBody))
+	body, err := s.handleRequest(ctx, PROXY, "POST", endpoint, bytes.NewBuffer(jsonRequestBody))
 	if err != nil {
 		return "", err
 	}
@@ -236,42 +832,306 @@ func (s *Spotify) CreatePlaylist(userID string, trackIDs []string) (string, erro
 	return response.ID, nil
 }
 
+// MaxPlaylistSize is the maximum number of items in a Spotify playlist.
+const MaxPlaylistSize = 10000
+
+/*
+SplitTracks splits trackIDs into parts that each fit in a playlist,
+keeping their order.
+*/
+func SplitTracks(trackIDs []string) [][]string {
+	return chunks(trackIDs, MaxPlaylistSize)
+}
+
+// PartName returns the name of part i (starting at 1) of n playlists.
+func PartName(name string, i, n int) string {
+	if n <= 1 {
+		return name
+	}
+	return fmt.Sprintf("%s (%d/%d)", name, i, n)
+}
+
+// Spotify limits you to max 100 URIs per request
+// so we need to be able to send tracks in batches.
+func chunks(trackIDs []string, size int) [][]string {
+	var result [][]string
+	for i := 0; i < len(trackIDs); i += size {
+		end := i + size
+		if end > len(trackIDs) {
+			end = len(trackIDs)
+		}
+		result = append(result, trackIDs[i:end])
+	}
+	return result
+}
+
 func (s *Spotify) AddTracksToPlaylist(
 	playlistID string,
 	trackIDs []string,
 	batchSize int,
 ) (string, error) {
-	// Spotify limits you to max 100 URIs per request
-	// so we need to be able to send tracks in batches.
-	chunks := func(trackIDs []string, size int) [][]string {
-		var result [][]string
-		for i := 0; i < len(trackIDs); i += size {
-			end := i + size
-			if end > len(trackIDs) {
-				end = len(trackIDs)
-			}
-			result = append(result, trackIDs[i:end])
+	return s.AddTracksToPlaylistContext(context.Background(), playlistID, trackIDs, batchSize)
+}
+
+// AddTracksToPlaylistContext is like AddTracksToPlaylist but includes a context.
+func (s *Spotify) AddTracksToPlaylistContext(
+	ctx context.Context,
+	playlistID string,
+	trackIDs []string,
+	batchSize int,
+) (string, error) {
+	batches := chunks(trackIDs, batchSize)
+	var lastSnapshotID string
+	for i, batch := range batches {
+		snapshotID, err := s.addTracks(ctx, playlistID, batch)
+		if err != nil {
+			return "", err
 		}
-		return result
+		lastSnapshotID = snapshotID
+		s.progress(ProgressEvent{
+			Stage:    StageAdd,
+			Playlist: Playlist{ID: playlistID},
+			Done:     i + 1,
+			Total:    len(batches),
+		})
+	}
+	return lastSnapshotID, nil
+}
+
+// addTracks appends a batch of tracks to the playlist.
+func (s *Spotify) addTracks(ctx context.Context, playlistID string, batch []string) (string, error) {
+	requestBody := map[string][]string{"uris": batch}
+	jsonRequestBody, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", err
+	}
+	endpoint := fmt.Sprintf("/playlists/%s/tracks", playlistID)
+	body, err := s.handleRequest(ctx, PROXY, "POST", endpoint, bytes.NewBuffer(jsonRequestBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to add tracks to playlist: %w", err)
+	}
+	var response AddTracksToPlaylistResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return response.SnapshotID, nil
+}
+
+/*
+ReplacePlaylistTracks replaces the items of a playlist with trackIDs.
+The first batch replaces the items in one request and the rest are
+added after it, so the playlist is never left empty if a batch fails.
+An empty trackIDs clears the playlist.
+*/
+func (s *Spotify) ReplacePlaylistTracks(
+	playlistID string,
+	trackIDs []string,
+	batchSize int,
+) (string, error) {
+	return s.ReplacePlaylistTracksContext(context.Background(), playlistID, trackIDs, batchSize)
+}
+
+// ReplacePlaylistTracksContext is like ReplacePlaylistTracks but includes a context.
+func (s *Spotify) ReplacePlaylistTracksContext(
+	ctx context.Context,
+	playlistID string,
+	trackIDs []string,
+	batchSize int,
+) (string, error) {
+	// Spotify clears the playlist for an empty list, but not for null.
+	first := append([]string{}, trackIDs[:min(batchSize, len(trackIDs))]...)
+	requestBody := map[string][]string{"uris": first}
+	jsonRequestBody, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", err
+	}
+	endpoint := fmt.Sprintf("/playlists/%s/tracks", playlistID)
+	body, err := s.handleRequest(ctx, PROXY, "PUT", endpoint, bytes.NewBuffer(jsonRequestBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to replace playlist tracks: %w", err)
 	}
+	var response AddTracksToPlaylistResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if len(first) == len(trackIDs) {
+		return response.SnapshotID, nil
+	}
+	return s.AddTracksToPlaylistContext(ctx, playlistID, trackIDs[len(first):], batchSize)
+}
+
+/*
+UnfollowPlaylist removes the playlist from the user's library,
+which is how Spotify deletes a playlist the user owns.
+*/
+func (s *Spotify) UnfollowPlaylist(playlistID string) error {
+	return s.UnfollowPlaylistContext(context.Background(), playlistID)
+}
+
+// UnfollowPlaylistContext is like UnfollowPlaylist but includes a context.
+func (s *Spotify) UnfollowPlaylistContext(ctx context.Context, playlistID string) error {
+	endpoint := fmt.Sprintf("/playlists/%s/followers", playlistID)
+	if _, err := s.handleRequest(ctx, PROXY, "DELETE", endpoint, nil); err != nil {
+		return fmt.Errorf("failed to unfollow playlist: %w", err)
+	}
+	return nil
+}
+
+func (s *Spotify) RemoveTracksFromPlaylist(
+	playlistID string,
+	trackIDs []string,
+	batchSize int,
+) (string, error) {
+	return s.RemoveTracksFromPlaylistContext(context.Background(), playlistID, trackIDs, batchSize)
+}
+
+// RemoveTracksFromPlaylistContext is like RemoveTracksFromPlaylist but includes a context.
+func (s *Spotify) RemoveTracksFromPlaylistContext(
+	ctx context.Context,
+	playlistID string,
+	trackIDs []string,
+	batchSize int,
+) (string, error) {
 	batches := chunks(trackIDs, batchSize)
 	var lastSnapshotID string
-	for _, batch := range batches {
-		requestBody := map[string][]string{"uris": batch}
+	for i, batch := range batches {
+		/*
+			Spotify removes every occurrence of
+			each URI sent in the request body.
+		*/
+		tracks := make([]map[string]string, 0, len(batch))
+		for _, uri := range batch {
+			tracks = append(tracks, map[string]string{"uri": uri})
+		}
+		requestBody := map[string][]map[string]string{"tracks": tracks}
 		jsonRequestBody, err := json.Marshal(requestBody)
 		if err != nil {
 			return "", err
 		}
 		endpoint := fmt.Sprintf("/playlists/%s/tracks", playlistID)
-		body, err := s.handleRequest(PROXY, "POST", endpoint, bytes.NewBuffer(jsonRequestBody))
+		body, err := s.handleRequest(ctx, PROXY, "DELETE", endpoint, bytes.NewBuffer(jsonRequestBody))
 		if err != nil {
-			return "", fmt.Errorf("failed to add tracks to playlist: %w", err)
+			return "", fmt.Errorf("failed to remove tracks from playlist: %w", err)
 		}
-		var response AddTracksToPlaylistResponse
+		var response RemoveTracksFromPlaylistResponse
 		if err := json.Unmarshal(body, &response); err != nil {
 			return "", fmt.Errorf("failed to unmarshal response: %w", err)
 		}
 		lastSnapshotID = response.SnapshotID
+		s.progress(ProgressEvent{
+			Stage:    StageRemove,
+			Playlist: Playlist{ID: playlistID},
+			Done:     i + 1,
+			Total:    len(batches),
+		})
 	}
 	return lastSnapshotID, nil
 }
+
+// ItemPosition is a playlist item at a position in a playlist snapshot.
+type ItemPosition struct {
+	URI      string
+	Position int
+}
+
+/*
+RemovePlaylistItems removes the items at the given positions of the
+playlist snapshot, leaving other occurrences of the same tracks in
+place. It returns the snapshot ID of the playlist after the removal.
+*/
+func (s *Spotify) RemovePlaylistItems(
+	playlistID string,
+	snapshotID string,
+	items []ItemPosition,
+	batchSize int,
+) (string, error) {
+	return s.RemovePlaylistItemsContext(context.Background(), playlistID, snapshotID, items, batchSize)
+}
+
+// RemovePlaylistItemsContext is like RemovePlaylistItems but includes a context.
+func (s *Spotify) RemovePlaylistItemsContext(
+	ctx context.Context,
+	playlistID string,
+	snapshotID string,
+	items []ItemPosition,
+	batchSize int,
+) (string, error) {
+	/*
+		Removes items from the end of the playlist first, so the
+		positions of the items left to remove do not shift between
+		batches that are made against newer snapshots.
+	*/
+	sorted := make([]ItemPosition, len(items))
+	copy(sorted, items)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Position > sorted[j].Position
+	})
+	batches := (len(sorted) + batchSize - 1) / batchSize
+	for i := 0; i < len(sorted); i += batchSize {
+		end := min(i+batchSize, len(sorted))
+		var tracks []map[string]any
+		for _, item := range sorted[i:end] {
+			tracks = append(tracks, map[string]any{
+				"uri":       item.URI,
+				"positions": []int{item.Position},
+			})
+		}
+		requestBody := map[string]any{
+			"tracks":      tracks,
+			"snapshot_id": snapshotID,
+		}
+		jsonRequestBody, err := json.Marshal(requestBody)
+		if err != nil {
+			return "", err
+		}
+		endpoint := fmt.Sprintf("/playlists/%s/tracks", playlistID)
+		body, err := s.handleRequest(ctx, PROXY, "DELETE", endpoint, bytes.NewBuffer(jsonRequestBody))
+		if err != nil {
+			return "", fmt.Errorf("failed to remove items from playlist: %w", err)
+		}
+		var response RemoveTracksFromPlaylistResponse
+		if err := json.Unmarshal(body, &response); err != nil {
+			return "", fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+		snapshotID = response.SnapshotID
+		s.progress(ProgressEvent{
+			Stage:    StageRemove,
+			Playlist: Playlist{ID: playlistID},
+			Done:     i/batchSize + 1,
+			Total:    batches,
+		})
+	}
+	return snapshotID, nil
+}
+
+/*
+DiffTracks compares the tracks currently in a playlist with the
+tracks it should contain, returning the tracks to add and remove.
+*/
+func DiffTracks(current, desired []string) ([]string, []string) {
+	inCurrent := make(map[string]bool)
+	for _, id := range current {
+		inCurrent[id] = true
+	}
+	inDesired := make(map[string]bool)
+	for _, id := range desired {
+		inDesired[id] = true
+	}
+	var toAdd []string
+	for _, id := range desired {
+		if !inCurrent[id] {
+			toAdd = append(toAdd, id)
+			// Prevents adding the same track twice.
+			inCurrent[id] = true
+		}
+	}
+	var toRemove []string
+	for _, id := range current {
+		if !inDesired[id] {
+			toRemove = append(toRemove, id)
+			// Prevents removing the same track twice.
+			inDesired[id] = true
+		}
+	}
+	return toAdd, toRemove
+}
@@ -1,11 +1,16 @@
 package spotify
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -47,7 +52,7 @@ func TestGetPlaylists(t *testing.T) {
 				roundTripFunc: func(req *http.Request) (*http.Response, error) {
 					return &http.Response{
 						StatusCode: http.StatusOK,
-						Body:       io.NopCloser(strings.NewReader(`{"items": [{"id": "123", "name": "foo"}, {"id": "456", "name": "bar"}], "next": null}`)),
+						Body:       io.NopCloser(strings.NewReader(`{"items": [{"id": "123", "name": "foo", "tracks": {"total": 12}}, {"id": "456", "name": "bar"}], "next": null}`)),
 					}, nil
 				},
 			},
@@ -56,9 +61,9 @@ func TestGetPlaylists(t *testing.T) {
 			Client: mockClient,
 			Token:  "mockToken",
 		}
-		playlists, err := s.getPlaylists("user")
+		playlists, err := s.getPlaylists(context.Background(), "user")
 		expected := []Playlist{
-			{ID: "123", Name: "foo"},
+			{ID: "123", Name: "foo", Tracks: &PlaylistTracksRef{Total: 12}},
 			{ID: "456", Name: "bar"},
 		}
 		assert.NoError(t, err, "failed to unmarshal profile")
@@ -86,7 +91,7 @@ func TestGetPlaylists(t *testing.T) {
 			Client: mockClient,
 			Token:  "mockToken",
 		}
-		playlists, err := s.getPlaylists("user")
+		playlists, err := s.getPlaylists(context.Background(), "user")
 		assert.NoError(t, err, "failed to unmarshal playlists")
 		expected := []Playlist{
 			{ID: "123", Name: "foo"},
@@ -113,7 +118,7 @@ func TestGetTracksFromPlaylist(t *testing.T) {
 			Client: mockClient,
 			Token:  "mockToken",
 		}
-		tracks, err := s.getTracksFromPlaylist("mockPlaylistID")
+		tracks, err := s.getTracksFromPlaylist(context.Background(), Playlist{ID: "mockPlaylistID"})
 		expected := []PlaylistTrack{
 			{
 				Track: Track{
@@ -134,7 +139,7 @@ func TestGetTracksFromPlaylist(t *testing.T) {
 		mockClient := &http.Client{
 			Transport: &mockRoundTripper{
 				roundTripFunc: func(req *http.Request) (*http.Response, error) {
-					if req.URL.String() == "http://localhost:3000/playlists/mockPlaylistID/tracks" {
+					if req.URL.Path == "/playlists/mockPlaylistID/tracks" {
 						return &http.Response{
 							StatusCode: http.StatusOK,
 							Body:       io.NopCloser(strings.NewReader(`{"items": [{"track": {"uri": "123"}}, {"track": {"uri": "456"}}], "next": "https://api.spotify.com/v1/users/user/playlists?offset=20"}`)),
@@ -151,7 +156,7 @@ func TestGetTracksFromPlaylist(t *testing.T) {
 			Client: mockClient,
 			Token:  "mockToken",
 		}
-		tracks, err := s.getTracksFromPlaylist("mockPlaylistID")
+		tracks, err := s.getTracksFromPlaylist(context.Background(), Playlist{ID: "mockPlaylistID"})
 		expected := []PlaylistTrack{
 			{
 				Track: Track{
@@ -177,6 +182,81 @@ func TestGetTracksFromPlaylist(t *testing.T) {
 		assert.NoError(t, err, "failed to unmarshal response")
 		assert.Equal(t, expected, tracks, "unexpected tracks returned")
 	})
+
+	t.Run("keeps added_at and release date", func(t *testing.T) {
+		mockClient := &http.Client{
+			Transport: &mockRoundTripper{
+				roundTripFunc: func(req *http.Request) (*http.Response, error) {
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(strings.NewReader(`{"items": [{"added_at": "2024-01-02T03:04:05Z", "track": {"uri": "123", "album": {"release_date": "1999-12"}}}], "next": null}`)),
+					}, nil
+				},
+			},
+		}
+		s := Spotify{
+			Client: mockClient,
+			Token:  "mockToken",
+		}
+		tracks, err := s.getTracksFromPlaylist(context.Background(), Playlist{ID: "mockPlaylistID"})
+		expected := []PlaylistTrack{
+			{
+				AddedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				Track: Track{
+					URI:   "123",
+					Album: Album{ReleaseDate: "1999-12"},
+				},
+			},
+		}
+		assert.NoError(t, err, "failed to unmarshal response")
+		assert.Equal(t, expected, tracks, "unexpected tracks returned")
+	})
+
+	t.Run("requests full track model", func(t *testing.T) {
+		mockClient := &http.Client{
+			Transport: &mockRoundTripper{
+				roundTripFunc: func(req *http.Request) (*http.Response, error) {
+					assert.Contains(t, req.URL.Query().Get("fields"), "external_ids.isrc")
+					assert.Equal(t, "from_token", req.URL.Query().Get("market"))
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body: io.NopCloser(strings.NewReader(`{"items": [{"added_at": "2024-01-02T03:04:05Z", "added_by": {"id": "user"}, "track": {
+							"uri": "spotify:track:1", "id": "1", "name": "Get Lucky",
+							"artists": [{"id": "a1", "name": "Daft Punk"}],
+							"album": {"id": "al1", "name": "Random Access Memories", "release_date": "2013-05-17"},
+							"duration_ms": 248413, "explicit": false, "popularity": 80,
+							"external_ids": {"isrc": "USQX91300108"}, "is_local": false, "is_playable": true
+						}}], "next": null}`)),
+					}, nil
+				},
+			},
+		}
+		s := Spotify{
+			Client: mockClient,
+			Token:  "mockToken",
+		}
+		tracks, err := s.getTracksFromPlaylist(context.Background(), Playlist{ID: "mockPlaylistID"})
+		playable := true
+		expected := []PlaylistTrack{
+			{
+				AddedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				AddedBy: User{ID: "user"},
+				Track: Track{
+					URI:         "spotify:track:1",
+					ID:          "1",
+					Name:        "Get Lucky",
+					Artists:     []Artist{{ID: "a1", Name: "Daft Punk"}},
+					Album:       Album{ID: "al1", Name: "Random Access Memories", ReleaseDate: "2013-05-17"},
+					DurationMS:  248413,
+					Popularity:  80,
+					ExternalIDs: ExternalIDs{ISRC: "USQX91300108"},
+					IsPlayable:  &playable,
+				},
+			},
+		}
+		assert.NoError(t, err, "failed to unmarshal response")
+		assert.Equal(t, expected, tracks, "unexpected tracks returned")
+	})
 }
 
 func TestAddTracksToPlaylist(t *testing.T) {
@@ -214,6 +294,38 @@ func TestAddTracksToPlaylist(t *testing.T) {
 		}
 		assert.Equal(t, expectedBatches, requests, "unexpected batch content")
 	})
+
+	t.Run("stops when the context is canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var added [][]string
+		mockClient := &http.Client{
+			Transport: &mockRoundTripper{
+				roundTripFunc: func(req *http.Request) (*http.Response, error) {
+					if err := req.Context().Err(); err != nil {
+						return nil, err
+					}
+					var body map[string][]string
+					if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+						t.Fatalf("failed to decode request body: %v", err)
+					}
+					added = append(added, body["uris"])
+					cancel()
+					return &http.Response{
+						StatusCode: http.StatusCreated,
+						Body:       io.NopCloser(strings.NewReader(`{"snapshot_id": "mockSnapshot123"}`)),
+					}, nil
+				},
+			},
+		}
+		s := Spotify{
+			Client: mockClient,
+			Token:  "mockToken",
+		}
+		trackIDs := []string{"track1", "track2", "track3"}
+		_, err := s.AddTracksToPlaylistContext(ctx, "mockPlaylistID", trackIDs, 2)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, [][]string{{"track1", "track2"}}, added)
+	})
 }
 
 func Test_GetPlaylistTracksIDs(t *testing.T) {
@@ -239,4 +351,506 @@ func Test_GetPlaylistTracksIDs(t *testing.T) {
 			assert.Equal(t, expected, tracks, "unexpected tracks returned")
 		})
 	})
+
+	t.Run("returns the URI stored for relinked tracks", func(t *testing.T) {
+		mockClient := &http.Client{
+			Transport: &mockRoundTripper{
+				roundTripFunc: func(req *http.Request) (*http.Response, error) {
+					assert.Contains(t, req.URL.Query().Get("fields"), "linked_from.uri")
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(strings.NewReader(`{"items": [{"track": {"uri": "123", "linked_from": {"uri": "456"}}}, {"track": {"uri": "789"}}], "next": null}`)),
+					}, nil
+				},
+			},
+		}
+		s := Spotify{
+			Client: mockClient,
+			Token:  "mockToken",
+		}
+		tracks, err := s.GetPlaylistTrackIDs([]string{"playListId1"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"456", "789"}, tracks)
+	})
+}
+
+func TestGetPlaylistID(t *testing.T) {
+	mockClient := &http.Client{
+		Transport: &mockRoundTripper{
+			roundTripFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(`{"items": [{"id": "123", "name": "foo"}, {"id": "456", "name": "bar"}, {"id": "789", "name": "baz"}, {"id": "012", "name": "baz"}], "next": null}`)),
+				}, nil
+			},
+		},
+	}
+	s := Spotify{
+		Client: mockClient,
+		Token:  "mockToken",
+	}
+
+	t.Run("matches by name", func(t *testing.T) {
+		id, err := s.GetPlaylistID("user", "bar")
+		assert.NoError(t, err)
+		assert.Equal(t, "456", id, "unexpected playlist ID returned")
+	})
+
+	t.Run("matches by ID", func(t *testing.T) {
+		id, err := s.GetPlaylistID("user", "123")
+		assert.NoError(t, err)
+		assert.Equal(t, "123", id, "unexpected playlist ID returned")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := s.GetPlaylistID("user", "qux")
+		assert.Error(t, err, "expected error for unknown playlist")
+	})
+
+	t.Run("ambiguous name", func(t *testing.T) {
+		_, err := s.GetPlaylistID("user", "baz")
+		var ambiguous *AmbiguousPlaylistError
+		assert.ErrorAs(t, err, &ambiguous)
+		assert.Equal(t, []string{"789", "012"}, ambiguous.IDs, "expected the IDs of both playlists")
+	})
+
+	t.Run("ID of a playlist with an ambiguous name", func(t *testing.T) {
+		id, err := s.GetPlaylistID("user", "012")
+		assert.NoError(t, err)
+		assert.Equal(t, "012", id, "unexpected playlist ID returned")
+	})
+}
+
+func TestRemoveTracksFromPlaylist(t *testing.T) {
+	t.Run("tracks are batched correctly", func(t *testing.T) {
+		var requests []map[string][]map[string]string
+		mockClient := &http.Client{
+			Transport: &mockRoundTripper{
+				roundTripFunc: func(req *http.Request) (*http.Response, error) {
+					assert.Equal(t, "DELETE", req.Method)
+					var body map[string][]map[string]string
+					if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+						t.Fatalf("failed to decode request body: %v", err)
+					}
+					requests = append(requests, body)
+					mockResponse := `{"snapshot_id": "mockSnapshot123"}`
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(strings.NewReader(mockResponse)),
+					}, nil
+				},
+			},
+		}
+		s := Spotify{
+			Client: mockClient,
+			Token:  "mockToken",
+		}
+		trackIDs := []string{"track1", "track2", "track3"}
+		response, err := s.RemoveTracksFromPlaylist("mockPlaylistID", trackIDs, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, "mockSnapshot123", response)
+		expectedBatches := []map[string][]map[string]string{
+			{"tracks": {{"uri": "track1"}, {"uri": "track2"}}},
+			{"tracks": {{"uri": "track3"}}},
+		}
+		assert.Equal(t, expectedBatches, requests, "unexpected batch content")
+	})
+}
+
+func TestDiffTracks(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		current := []string{"1", "2", "3", "3"}
+		desired := []string{"2", "3", "4", "5", "4"}
+		toAdd, toRemove := DiffTracks(current, desired)
+		assert.Equal(t, []string{"4", "5"}, toAdd, "unexpected tracks to add")
+		assert.Equal(t, []string{"1"}, toRemove, "unexpected tracks to remove")
+	})
+
+	t.Run("in sync", func(t *testing.T) {
+		toAdd, toRemove := DiffTracks([]string{"1", "2"}, []string{"2", "1"})
+		assert.Empty(t, toAdd)
+		assert.Empty(t, toRemove)
+	})
+}
+
+func TestMatchPlaylists(t *testing.T) {
+	mockClient := &http.Client{
+		Transport: &mockRoundTripper{
+			roundTripFunc: func(req *http.Request) (*http.Response, error) {
+				t.Fatalf("unexpected request: %s", req.URL)
+				return nil, nil
+			},
+		},
+	}
+	s := Spotify{
+		Client: mockClient,
+		Token:  "mockToken",
+	}
+	library := []Playlist{
+		{ID: "123", Name: "foo", SnapshotID: "snap"},
+		{ID: "456", Name: "foo 2024"},
+	}
+	result, err := s.MatchPlaylists(library, []Source{{Name: "foo"}, {Match: "* 2024"}}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, library, result.Matched, "expected the listed playlists to be matched")
+}
+
+func TestGetPlaylistIDsByName(t *testing.T) {
+	mockClient := &http.Client{
+		Transport: &mockRoundTripper{
+			roundTripFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(`{"items": [{"id": "123", "name": "foo", "snapshot_id": "snap"}, {"id": "456", "name": "bar"}, {"id": "789", "name": "bar"}], "next": null}`)),
+				}, nil
+			},
+		},
+	}
+	s := Spotify{
+		Client: mockClient,
+		Token:  "mockToken",
+	}
+
+	t.Run("happy path", func(t *testing.T) {
+		result, err := s.GetPlaylistIDsByName("user", []Source{{Name: "foo"}}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"123"}, result.IDs(), "unexpected playlist IDs")
+		assert.Equal(t, "snap", result.Matched[0].SnapshotID, "expected the snapshot ID from the listing")
+		assert.Empty(t, result.Missing)
+		assert.Empty(t, result.Ambiguous)
+	})
+
+	t.Run("reports missing playlists", func(t *testing.T) {
+		result, err := s.GetPlaylistIDsByName("user", []Source{{Name: "fooo"}, {Name: "foo"}}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"123"}, result.IDs(), "unexpected playlist IDs")
+		expected := []MissingPlaylist{
+			{Name: "fooo", Suggestions: []string{"foo"}},
+		}
+		assert.Equal(t, expected, result.Missing, "unexpected missing playlists")
+	})
+
+	t.Run("reports ambiguous playlists", func(t *testing.T) {
+		result, err := s.GetPlaylistIDsByName("user", []Source{{Name: "bar"}, {Name: "foo"}}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"456", "123"}, result.IDs(), "unexpected playlist IDs")
+		expected := []AmbiguousPlaylist{
+			{Name: "bar", IDs: []string{"456", "789"}},
+		}
+		assert.Equal(t, expected, result.Ambiguous, "unexpected ambiguous playlists")
+	})
+
+	t.Run("resolves playlist URLs, URIs and IDs", func(t *testing.T) {
+		mockClient := &http.Client{
+			Transport: &mockRoundTripper{
+				roundTripFunc: func(req *http.Request) (*http.Response, error) {
+					if req.URL.Path == "/users/user/playlists" {
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(strings.NewReader(`{"items": [{"id": "123", "name": "foo"}], "next": null}`)),
+						}, nil
+					}
+					if req.URL.Path == "/playlists/2pyrktawyLSFKVIYd01cjP" {
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(strings.NewReader(`{"id": "2pyrktawyLSFKVIYd01cjP", "name": "shared"}`)),
+						}, nil
+					}
+					return &http.Response{
+						StatusCode: http.StatusNotFound,
+						Body:       io.NopCloser(strings.NewReader(`{"error": {"status": 404}}`)),
+					}, nil
+				},
+			},
+		}
+		s := Spotify{
+			Client: mockClient,
+			Token:  "mockToken",
+		}
+		result, err := s.GetPlaylistIDsByName("user", []Source{
+			{Name: "https://open.spotify.com/playlist/2pyrktawyLSFKVIYd01cjP?si=abc"},
+			{Name: "spotify:playlist:2pyrktawyLSFKVIYd01cjP"},
+			{Name: "foo"},
+			{Name: "0000000000000000000000"},
+		}, nil)
+		assert.NoError(t, err)
+		expected := []Playlist{
+			{ID: "2pyrktawyLSFKVIYd01cjP", Name: "shared"},
+			{ID: "123", Name: "foo"},
+		}
+		assert.Equal(t, expected, result.Matched, "unexpected matched playlists")
+		assert.Equal(t, "0000000000000000000000", result.Missing[0].Name, "unexpected missing playlist")
+	})
+
+	t.Run("expands patterns", func(t *testing.T) {
+		mockClient := &http.Client{
+			Transport: &mockRoundTripper{
+				roundTripFunc: func(req *http.Request) (*http.Response, error) {
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(strings.NewReader(`{"items": [{"id": "1", "name": "Mar 2024"}, {"id": "2", "name": "Feb 2024"}, {"id": "3", "name": "Jan 2023"}, {"id": "4", "name": "Monthly Mix"}, {"id": "5", "name": "Jan 2024 (draft)"}], "next": null}`)),
+					}, nil
+				},
+			},
+		}
+		s := Spotify{
+			Client: mockClient,
+			Token:  "mockToken",
+		}
+		result, err := s.GetPlaylistIDsByName(
+			"user",
+			[]Source{{Name: "Mar 2024"}, {Match: "* 2024*"}, {Regex: "^Monthly"}, {Match: "Dec *"}},
+			[]Source{{Regex: `\(draft\)$`}},
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1", "2", "4"}, result.IDs(), "unexpected playlist IDs")
+		assert.Equal(t, []MissingPlaylist{{Name: "Dec *"}}, result.Missing, "unexpected missing playlists")
+	})
+
+	t.Run("matches each playlist once", func(t *testing.T) {
+		mockClient := &http.Client{
+			Transport: &mockRoundTripper{
+				roundTripFunc: func(req *http.Request) (*http.Response, error) {
+					assert.Equal(t, "/users/user/playlists", req.URL.Path, "expected no lookup of a matched playlist")
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(strings.NewReader(`{"items": [{"id": "2pyrktawyLSFKVIYd01cjP", "name": "Jan 2024"}, {"id": "2", "name": "Feb 2024"}], "next": null}`)),
+					}, nil
+				},
+			},
+		}
+		s := Spotify{
+			Client: mockClient,
+			Token:  "mockToken",
+		}
+		result, err := s.GetPlaylistIDsByName("user", []Source{
+			{Match: "* 2024"},
+			{Name: "Jan 2024"},
+			{Name: "Feb 2024"},
+			{Name: "https://open.spotify.com/playlist/2pyrktawyLSFKVIYd01cjP"},
+		}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"2", "2pyrktawyLSFKVIYd01cjP"}, result.IDs(), "unexpected playlist IDs")
+		assert.Empty(t, result.Missing)
+	})
+
+	t.Run("matches file sources", func(t *testing.T) {
+		result, err := s.GetPlaylistIDsByName("user", []Source{{File: "tracks.csv"}, {Name: "foo"}}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"file:tracks.csv", "123"}, result.IDs(), "unexpected playlist IDs")
+	})
+}
+
+func TestCreatePlaylistWithName(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		var body map[string]string
+		mockClient := &http.Client{
+			Transport: &mockRoundTripper{
+				roundTripFunc: func(req *http.Request) (*http.Response, error) {
+					if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+						t.Fatalf("failed to decode request body: %v", err)
+					}
+					return &http.Response{
+						StatusCode: http.StatusCreated,
+						Body:       io.NopCloser(strings.NewReader(`{"id": "playlist123"}`)),
+					}, nil
+				},
+			},
+		}
+		s := Spotify{
+			Client: mockClient,
+			Token:  "mockToken",
+		}
+		id, err := s.CreatePlaylistWithName("user", "Archive (1/2)", []string{"track1"})
+		assert.NoError(t, err)
+		assert.Equal(t, "playlist123", id)
+		assert.Equal(t, "Archive (1/2)", body["name"], "unexpected playlist name")
+	})
+
+	t.Run("no tracks", func(t *testing.T) {
+		s := Spotify{Token: "mockToken"}
+		_, err := s.CreatePlaylistWithName("user", "Archive", nil)
+		assert.Error(t, err, "expected error for empty playlist")
+	})
+}
+
+func TestCreatePlaylistWithDescription(t *testing.T) {
+	var body map[string]string
+	mockClient := &http.Client{
+		Transport: &mockRoundTripper{
+			roundTripFunc: func(req *http.Request) (*http.Response, error) {
+				if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+					t.Fatalf("failed to decode request body: %v", err)
+				}
+				return &http.Response{
+					StatusCode: http.StatusCreated,
+					Body:       io.NopCloser(strings.NewReader(`{"id": "playlist123"}`)),
+				}, nil
+			},
+		},
+	}
+	s := Spotify{
+		Client: mockClient,
+		Token:  "mockToken",
+	}
+	id, err := s.CreatePlaylistWithDescription("user", "Archive", "Old favorites")
+	assert.NoError(t, err)
+	assert.Equal(t, "playlist123", id)
+	assert.Equal(t, map[string]string{"name": "Archive", "description": "Old favorites"}, body)
+}
+
+func TestReplacePlaylistTracks(t *testing.T) {
+	type request struct {
+		Method string
+		URIs   []string
+	}
+	newSpotify := func(requests *[]request) Spotify {
+		mockClient := &http.Client{
+			Transport: &mockRoundTripper{
+				roundTripFunc: func(req *http.Request) (*http.Response, error) {
+					var body map[string][]string
+					if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+						t.Fatalf("failed to decode request body: %v", err)
+					}
+					*requests = append(*requests, request{Method: req.Method, URIs: body["uris"]})
+					status := http.StatusOK
+					if req.Method == "POST" {
+						status = http.StatusCreated
+					}
+					return &http.Response{
+						StatusCode: status,
+						Body:       io.NopCloser(strings.NewReader(`{"snapshot_id": "mockSnapshot123"}`)),
+					}, nil
+				},
+			},
+		}
+		return Spotify{Client: mockClient, Token: "mockToken"}
+	}
+
+	t.Run("replaces the first batch and adds the rest", func(t *testing.T) {
+		var requests []request
+		s := newSpotify(&requests)
+		snapshotID, err := s.ReplacePlaylistTracks("mockPlaylistID", []string{"track1", "track2", "track3"}, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, "mockSnapshot123", snapshotID)
+		expected := []request{
+			{Method: "PUT", URIs: []string{"track1", "track2"}},
+			{Method: "POST", URIs: []string{"track3"}},
+		}
+		assert.Equal(t, expected, requests)
+	})
+
+	t.Run("clears the playlist", func(t *testing.T) {
+		var requests []request
+		s := newSpotify(&requests)
+		_, err := s.ReplacePlaylistTracks("mockPlaylistID", nil, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []request{{Method: "PUT", URIs: []string{}}}, requests)
+	})
+}
+
+func TestSplitTracks(t *testing.T) {
+	trackIDs := make([]string, MaxPlaylistSize*2+1)
+	for i := range trackIDs {
+		trackIDs[i] = fmt.Sprint(i)
+	}
+	parts := SplitTracks(trackIDs)
+	assert.Len(t, parts, 3)
+	assert.Len(t, parts[0], MaxPlaylistSize)
+	assert.Len(t, parts[2], 1)
+	assert.Equal(t, "0", parts[0][0])
+	assert.Equal(t, fmt.Sprint(MaxPlaylistSize), parts[1][0])
+	assert.Equal(t, "Archive (2/3)", PartName("Archive", 2, 3))
+	assert.Equal(t, "Archive", PartName("Archive", 1, 1))
+}
+
+func TestRemovePlaylistItems(t *testing.T) {
+	t.Run("removes positions from the end first", func(t *testing.T) {
+		var requests []map[string]any
+		snapshots := []string{"snap2", "snap3"}
+		mockClient := &http.Client{
+			Transport: &mockRoundTripper{
+				roundTripFunc: func(req *http.Request) (*http.Response, error) {
+					assert.Equal(t, "DELETE", req.Method)
+					var body map[string]any
+					if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+						t.Fatalf("failed to decode request body: %v", err)
+					}
+					requests = append(requests, body)
+					mockResponse := fmt.Sprintf(`{"snapshot_id": "%s"}`, snapshots[len(requests)-1])
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(strings.NewReader(mockResponse)),
+					}, nil
+				},
+			},
+		}
+		s := Spotify{
+			Client: mockClient,
+			Token:  "mockToken",
+		}
+		items := []ItemPosition{{URI: "a", Position: 2}, {URI: "b", Position: 9}, {URI: "a", Position: 5}}
+		snapshotID, err := s.RemovePlaylistItems("mockPlaylistID", "snap1", items, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, "snap3", snapshotID)
+		expected := []map[string]any{
+			{
+				"snapshot_id": "snap1",
+				"tracks": []any{
+					map[string]any{"uri": "b", "positions": []any{float64(9)}},
+					map[string]any{"uri": "a", "positions": []any{float64(5)}},
+				},
+			},
+			{
+				"snapshot_id": "snap2",
+				"tracks": []any{
+					map[string]any{"uri": "a", "positions": []any{float64(2)}},
+				},
+			},
+		}
+		assert.Equal(t, expected, requests, "unexpected batch content")
+	})
+}
+
+func TestSnapshots(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "tracks.txt")
+		assert.NoError(t, os.WriteFile(file, []byte("spotify:track:1\n"), 0o600))
+		modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		assert.NoError(t, os.Chtimes(file, modTime, modTime))
+		matches := PlaylistMatches{Matched: []Playlist{
+			{ID: "p1", SnapshotID: "snap1"},
+			{ID: FileSourceID(file), Name: file},
+		}}
+		snapshots, err := matches.Snapshots()
+		assert.NoError(t, err)
+		expected := map[string]string{
+			"p1":               "snap1",
+			FileSourceID(file): "2024-01-02T03:04:05Z",
+		}
+		assert.Equal(t, expected, snapshots)
+	})
+}
+
+func TestUnfollowPlaylist(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		mockClient := &http.Client{
+			Transport: &mockRoundTripper{
+				roundTripFunc: func(req *http.Request) (*http.Response, error) {
+					assert.Equal(t, "DELETE", req.Method)
+					assert.Equal(t, "/playlists/mockPlaylistID/followers", req.URL.Path)
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(strings.NewReader("")),
+					}, nil
+				},
+			},
+		}
+		s := Spotify{
+			Client: mockClient,
+			Token:  "mockToken",
+		}
+		err := s.UnfollowPlaylist("mockPlaylistID")
+		assert.NoError(t, err)
+	})
 }
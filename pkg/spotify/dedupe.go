@@ -0,0 +1,169 @@
+package spotify
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Dedupe is how tracks are identified when omitting duplicates.
+type Dedupe string
+
+const (
+	// DedupeURI treats tracks as duplicates if they have the same URI.
+	DedupeURI Dedupe = "uri"
+	// DedupeISRC treats tracks as duplicates if they have the same ISRC.
+	DedupeISRC Dedupe = "isrc"
+	// DedupeFuzzy treats tracks as duplicates if they have the same normalized title and primary artist.
+	DedupeFuzzy Dedupe = "fuzzy"
+)
+
+// Keep is which version of a duplicated track is kept.
+type Keep string
+
+const (
+	KeepFirst    Keep = "first"
+	KeepPopular  Keep = "popular"
+	KeepEarliest Keep = "earliest"
+)
+
+func (d Dedupe) key() (func(t Track) string, error) {
+	switch d {
+	case DedupeURI, "":
+		return func(t Track) string {
+			return t.URI
+		}, nil
+	case DedupeISRC:
+		return func(t Track) string {
+			// Local files and some uploads have no ISRC.
+			if t.ExternalIDs.ISRC == "" {
+				return t.URI
+			}
+			return "isrc:" + strings.ToUpper(t.ExternalIDs.ISRC)
+		}, nil
+	case DedupeFuzzy:
+		return func(t Track) string {
+			if t.Name == "" {
+				return t.URI
+			}
+			artist := ""
+			if len(t.Artists) > 0 {
+				artist = normalize(t.Artists[0].Name)
+			}
+			return "fuzzy:" + NormalizeTitle(t.Name) + "|" + artist
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown dedupe: %s", d)
+}
+
+/*
+better reports whether candidate should be kept over current. Ties
+keep current, so the first version seen wins unless another is better.
+*/
+func (k Keep) better() (func(candidate, current PlaylistTrack) bool, error) {
+	switch k {
+	case KeepFirst, "":
+		return func(candidate, current PlaylistTrack) bool {
+			return false
+		}, nil
+	case KeepPopular:
+		return func(candidate, current PlaylistTrack) bool {
+			return candidate.Track.Popularity > current.Track.Popularity
+		}, nil
+	case KeepEarliest:
+		return func(candidate, current PlaylistTrack) bool {
+			a, b := candidate.Track.Album.ReleaseDate, current.Track.Album.ReleaseDate
+			return a != "" && (b == "" || a < b)
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown keep: %s", k)
+}
+
+var (
+	// Matches bracketed or dashed suffixes such as "(feat. X)" or " - 2011 Remaster".
+	versionPattern = regexp.MustCompile(
+		`\s*(\([^)]*\)|\[[^\]]*\]|\s-\s.*)`,
+	)
+	// Matches the words that mark a suffix as a remaster or a featured artist.
+	versionKeywords = regexp.MustCompile(`\b(remaster|remastered|feat|ft|featuring)\b`)
+	// Matches an unbracketed "feat. X" or "ft. X" to the end of the title.
+	featPattern = regexp.MustCompile(`\s+(feat\.?|ft\.|featuring)\s.*$`)
+)
+
+/*
+NormalizeTitle strips version information such as "Remastered"
+or "feat." and punctuation from a track title, so that different
+releases of the same song compare equal.
+*/
+func NormalizeTitle(title string) string {
+	title = strings.ToLower(title)
+	title = versionPattern.ReplaceAllStringFunc(title, func(match string) string {
+		// Remixes and live recordings are kept apart from the original.
+		if versionKeywords.MatchString(match) {
+			return ""
+		}
+		return match
+	})
+	title = featPattern.ReplaceAllString(title, "")
+	return normalize(title)
+}
+
+// normalize lowercases s and collapses punctuation and whitespace into single spaces.
+func normalize(s string) string {
+	s = strings.ToLower(s)
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// DuplicateItem is a later occurrence of a track already in a playlist.
+type DuplicateItem struct {
+	Position int
+	Item     PlaylistTrack
+	// FirstPosition is the position of the first occurrence of the track.
+	FirstPosition int
+}
+
+/*
+FindDuplicates returns the later occurrences of tracks that dedupe
+identifies as the same within a playlist's items. Items that cannot
+be removed by URI, e.g. deleted tracks, are never reported.
+*/
+func FindDuplicates(items []PlaylistTrack, dedupe Dedupe) ([]DuplicateItem, error) {
+	key, err := dedupe.key()
+	if err != nil {
+		return nil, err
+	}
+	var duplicates []DuplicateItem
+	first := make(map[string]int)
+	for i, p := range items {
+		if p.Track.URI == "" {
+			continue
+		}
+		k := key(p.Track)
+		if position, exists := first[k]; exists {
+			duplicates = append(duplicates, DuplicateItem{
+				Position:      i,
+				Item:          p,
+				FirstPosition: position,
+			})
+			continue
+		}
+		first[k] = i
+	}
+	return duplicates, nil
+}
+
+// DuplicatePositions returns the URI and position of each duplicate.
+func DuplicatePositions(duplicates []DuplicateItem) []ItemPosition {
+	var items []ItemPosition
+	for _, duplicate := range duplicates {
+		items = append(items, ItemPosition{
+			URI:      duplicate.Item.Track.PlaylistURI(),
+			Position: duplicate.Position,
+		})
+	}
+	return items
+}
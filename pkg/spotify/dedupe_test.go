@@ -0,0 +1,72 @@
+package spotify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTitle(t *testing.T) {
+	tests := []struct {
+		title    string
+		expected string
+	}{
+		{"Here Comes the Sun - Remastered 2009", "here comes the sun"},
+		{"Here Comes the Sun - 2009 Remaster", "here comes the sun"},
+		{"Get Lucky (feat. Pharrell Williams & Nile Rodgers)", "get lucky"},
+		{"Get Lucky (Ft. Pharrell Williams)", "get lucky"},
+		{"Get Lucky feat. Pharrell Williams", "get lucky"},
+		{"Don't Stop Me Now [Remastered]", "don t stop me now"},
+		{"(I Can't Get No) Satisfaction", "i can t get no satisfaction"},
+		{"Song 2", "song 2"},
+		{"Song (Skrillex Remix)", "song skrillex remix"},
+		{"Song (Live at Wembley)", "song live at wembley"},
+		{"Song - Live", "song live"},
+		{"Here Comes The Sun (2019 Mix)", "here comes the sun 2019 mix"},
+		{"Stayin' (Alive)", "stayin alive"},
+		{"Hello (With Love)", "hello with love"},
+		{"Softcore (Unremastered Demo)", "softcore unremastered demo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NormalizeTitle(tt.title))
+		})
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	items := []PlaylistTrack{
+		{Track: Track{URI: "1", ExternalIDs: ExternalIDs{ISRC: "X"}}},
+		{Track: Track{URI: "2"}},
+		{Track: Track{URI: "1", ExternalIDs: ExternalIDs{ISRC: "X"}}},
+		{Track: Track{}},
+		{Track: Track{URI: "3", ExternalIDs: ExternalIDs{ISRC: "X"}}},
+		{Track: Track{}},
+	}
+
+	t.Run("by uri", func(t *testing.T) {
+		duplicates, err := FindDuplicates(items, DedupeURI)
+		assert.NoError(t, err)
+		expected := []ItemPosition{{URI: "1", Position: 2}}
+		assert.Equal(t, expected, DuplicatePositions(duplicates))
+		assert.Equal(t, 0, duplicates[0].FirstPosition)
+	})
+
+	t.Run("by isrc", func(t *testing.T) {
+		duplicates, err := FindDuplicates(items, DedupeISRC)
+		assert.NoError(t, err)
+		expected := []ItemPosition{{URI: "1", Position: 2}, {URI: "3", Position: 4}}
+		assert.Equal(t, expected, DuplicatePositions(duplicates))
+	})
+
+	t.Run("relinked tracks", func(t *testing.T) {
+		relinked := []PlaylistTrack{
+			{Track: Track{URI: "1"}},
+			{Track: Track{URI: "1", LinkedFrom: &TrackRef{URI: "2"}}},
+		}
+		duplicates, err := FindDuplicates(relinked, DedupeURI)
+		assert.NoError(t, err)
+		expected := []ItemPosition{{URI: "2", Position: 1}}
+		assert.Equal(t, expected, DuplicatePositions(duplicates))
+	})
+}
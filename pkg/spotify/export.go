@@ -0,0 +1,182 @@
+package spotify
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is a file format tracks can be exported to.
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatJSON Format = "json"
+	FormatM3U  Format = "m3u8"
+	FormatXSPF Format = "xspf"
+)
+
+// FormatFromPath returns the export format matching the extension of path.
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".json":
+		return FormatJSON, nil
+	case ".m3u", ".m3u8":
+		return FormatM3U, nil
+	case ".xspf":
+		return FormatXSPF, nil
+	}
+	return "", fmt.Errorf("unsupported export file extension: %q (use .csv, .json, .m3u8 or .xspf)", filepath.Ext(path))
+}
+
+/*
+ExportFile writes the tracks to path in the format matching its
+extension. name is used as the playlist title where the format has one.
+*/
+func ExportFile(path, name string, tracks []PlaylistTrack) error {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	if err := Export(file, format, name, tracks); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Export writes the tracks to w in format.
+func Export(w io.Writer, format Format, name string, tracks []PlaylistTrack) error {
+	switch format {
+	case FormatCSV:
+		return exportCSV(w, tracks)
+	case FormatJSON:
+		return exportJSON(w, tracks)
+	case FormatM3U:
+		return exportM3U(w, tracks)
+	case FormatXSPF:
+		return exportXSPF(w, name, tracks)
+	}
+	return fmt.Errorf("unknown export format: %s", format)
+}
+
+func exportCSV(w io.Writer, tracks []PlaylistTrack) error {
+	writer := csv.NewWriter(w)
+	header := []string{
+		"uri", "name", "artists", "album", "release_date", "duration_ms",
+		"explicit", "popularity", "isrc", "added_at", "added_by",
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, p := range tracks {
+		addedAt := ""
+		if !p.AddedAt.IsZero() {
+			addedAt = p.AddedAt.Format(time.RFC3339)
+		}
+		record := []string{
+			p.Track.URI,
+			p.Track.Name,
+			p.Track.ArtistNames(),
+			p.Track.Album.Name,
+			p.Track.Album.ReleaseDate,
+			strconv.Itoa(p.Track.DurationMS),
+			strconv.FormatBool(p.Track.Explicit),
+			strconv.Itoa(p.Track.Popularity),
+			p.Track.ExternalIDs.ISRC,
+			addedAt,
+			p.AddedBy.ID,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func exportJSON(w io.Writer, tracks []PlaylistTrack) error {
+	if tracks == nil {
+		tracks = []PlaylistTrack{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(tracks)
+}
+
+func exportM3U(w io.Writer, tracks []PlaylistTrack) error {
+	if _, err := fmt.Fprintln(w, "#EXTM3U"); err != nil {
+		return err
+	}
+	for _, p := range tracks {
+		_, err := fmt.Fprintf(
+			w,
+			"#EXTINF:%d,%s - %s\n%s\n",
+			p.Track.DurationMS/1000,
+			p.Track.ArtistNames(),
+			p.Track.Name,
+			p.Track.URI,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type xspfTrack struct {
+	Location   string `xml:"location"`
+	Identifier string `xml:"identifier,omitempty"`
+	Title      string `xml:"title,omitempty"`
+	Creator    string `xml:"creator,omitempty"`
+	Album      string `xml:"album,omitempty"`
+	Duration   int    `xml:"duration,omitempty"`
+}
+
+type xspfPlaylist struct {
+	XMLName xml.Name    `xml:"http://xspf.org/ns/0/ playlist"`
+	Version string      `xml:"version,attr"`
+	Title   string      `xml:"title,omitempty"`
+	Tracks  []xspfTrack `xml:"trackList>track"`
+}
+
+func exportXSPF(w io.Writer, name string, tracks []PlaylistTrack) error {
+	playlist := xspfPlaylist{Version: "1", Title: name}
+	for _, p := range tracks {
+		identifier := ""
+		if p.Track.ExternalIDs.ISRC != "" {
+			identifier = "isrc:" + p.Track.ExternalIDs.ISRC
+		}
+		playlist.Tracks = append(playlist.Tracks, xspfTrack{
+			Location:   p.Track.URI,
+			Identifier: identifier,
+			Title:      p.Track.Name,
+			Creator:    p.Track.ArtistNames(),
+			Album:      p.Track.Album.Name,
+			Duration:   p.Track.DurationMS,
+		})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(playlist); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
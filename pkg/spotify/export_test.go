@@ -0,0 +1,88 @@
+package spotify
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var exportTracks = []PlaylistTrack{
+	{
+		AddedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		AddedBy: User{ID: "user"},
+		Track: Track{
+			URI:         "spotify:track:1",
+			Name:        "Get Lucky",
+			Artists:     []Artist{{Name: "Daft Punk"}, {Name: "Pharrell Williams"}},
+			Album:       Album{Name: "Random Access Memories", ReleaseDate: "2013-05-17"},
+			DurationMS:  248413,
+			Popularity:  80,
+			ExternalIDs: ExternalIDs{ISRC: "USQX91300108"},
+		},
+	},
+}
+
+func TestFormatFromPath(t *testing.T) {
+	tests := map[string]Format{
+		"tracks.csv":  FormatCSV,
+		"tracks.JSON": FormatJSON,
+		"tracks.m3u":  FormatM3U,
+		"tracks.m3u8": FormatM3U,
+		"tracks.xspf": FormatXSPF,
+	}
+	for path, expected := range tests {
+		format, err := FormatFromPath(path)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, format, path)
+	}
+	_, err := FormatFromPath("tracks.txt")
+	assert.Error(t, err, "expected error for unsupported extension")
+}
+
+func TestExport(t *testing.T) {
+	t.Run("csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, Export(&buf, FormatCSV, "Mix", exportTracks))
+		expected := "uri,name,artists,album,release_date,duration_ms,explicit,popularity,isrc,added_at,added_by\n" +
+			"spotify:track:1,Get Lucky,\"Daft Punk, Pharrell Williams\",Random Access Memories,2013-05-17,248413,false,80,USQX91300108,2024-01-02T03:04:05Z,user\n"
+		assert.Equal(t, expected, buf.String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, Export(&buf, FormatJSON, "Mix", exportTracks))
+		var tracks []PlaylistTrack
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &tracks))
+		assert.Equal(t, exportTracks, tracks)
+	})
+
+	t.Run("m3u8", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, Export(&buf, FormatM3U, "Mix", exportTracks))
+		expected := "#EXTM3U\n#EXTINF:248,Daft Punk, Pharrell Williams - Get Lucky\nspotify:track:1\n"
+		assert.Equal(t, expected, buf.String())
+	})
+
+	t.Run("xspf", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, Export(&buf, FormatXSPF, "Mix", exportTracks))
+		assert.Contains(t, buf.String(), `<playlist xmlns="http://xspf.org/ns/0/" version="1">`)
+		assert.Contains(t, buf.String(), "<title>Mix</title>")
+		assert.Contains(t, buf.String(), "<location>spotify:track:1</location>")
+		assert.Contains(t, buf.String(), "<identifier>isrc:USQX91300108</identifier>")
+		assert.Contains(t, buf.String(), "<duration>248413</duration>")
+	})
+}
+
+func TestExportFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tracks.m3u8")
+	assert.NoError(t, ExportFile(path, "Mix", exportTracks))
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "spotify:track:1")
+}
@@ -0,0 +1,180 @@
+package spotify
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Prefixes the IDs of file sources so they cannot clash with playlist IDs.
+const fileSourcePrefix = "file:"
+
+// FileSourceID returns the ID GetPlaylistTracks uses to read tracks from path.
+func FileSourceID(path string) string {
+	return fileSourcePrefix + path
+}
+
+// FileLine is a track listed in a file source, by URI or by artist and title.
+type FileLine struct {
+	URI    string
+	Artist string
+	Title  string
+}
+
+/*
+ParseTrackFile reads the tracks listed in a file source. CSV files
+(.csv) use their "uri" column, or their "artists" and "name" (or
+"artist" and "title") columns. Other files list one track per line,
+either as a Spotify track URI, URL or ID, or as "Artist - Title".
+Blank lines and lines starting with "#", e.g. M3U comments, are ignored.
+*/
+func ParseTrackFile(r io.Reader, path string) ([]FileLine, error) {
+	if strings.ToLower(filepath.Ext(path)) == ".csv" {
+		return parseTrackCSV(r)
+	}
+	var lines []FileLine
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, parseTrackLine(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return lines, nil
+}
+
+func parseTrackCSV(r io.Reader) ([]FileLine, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	columns := make(map[string]int)
+	for i, column := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	field := func(record []string, names ...string) string {
+		for _, name := range names {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+		}
+		return ""
+	}
+	_, hasURI := columns["uri"]
+	_, hasName := columns["name"]
+	_, hasTitle := columns["title"]
+	if !hasURI && !hasName && !hasTitle {
+		// Without a header, each row holds a single track.
+		var lines []FileLine
+		for _, record := range records {
+			if len(record) > 0 && strings.TrimSpace(record[0]) != "" {
+				lines = append(lines, parseTrackLine(strings.TrimSpace(record[0])))
+			}
+		}
+		return lines, nil
+	}
+	var lines []FileLine
+	for _, record := range records[1:] {
+		if uri, ok := ParseTrackURI(field(record, "uri")); ok {
+			lines = append(lines, FileLine{URI: uri})
+			continue
+		}
+		title := field(record, "name", "title")
+		if title == "" {
+			continue
+		}
+		artist := field(record, "artists", "artist")
+		// Exported CSVs list every artist, but search works best with the first.
+		artist, _, _ = strings.Cut(artist, ",")
+		lines = append(lines, FileLine{Artist: strings.TrimSpace(artist), Title: title})
+	}
+	return lines, nil
+}
+
+func parseTrackLine(line string) FileLine {
+	if uri, ok := ParseTrackURI(line); ok {
+		return FileLine{URI: uri}
+	}
+	artist, title, found := strings.Cut(line, " - ")
+	if !found {
+		return FileLine{Title: line}
+	}
+	return FileLine{Artist: strings.TrimSpace(artist), Title: strings.TrimSpace(title)}
+}
+
+/*
+getTracksFromFile reads the tracks listed in a file source, looking
+up URI lines by ID and resolving "Artist - Title" lines with Spotify
+search. Lines that cannot be resolved are returned as tracks with a
+name but no URI, so that SkipUnmergeable reports them.
+*/
+func (s *Spotify) getTracksFromFile(ctx context.Context, path string) ([]PlaylistTrack, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file source: %w", err)
+	}
+	defer file.Close()
+	lines, err := ParseTrackFile(file, path)
+	if err != nil {
+		return nil, err
+	}
+	var uris []string
+	for _, line := range lines {
+		if line.URI != "" {
+			uris = append(uris, line.URI)
+		}
+	}
+	found, err := s.lookupTracks(ctx, uris)
+	if err != nil {
+		return nil, err
+	}
+	var tracks []PlaylistTrack
+	for i, line := range lines {
+		s.progress(ProgressEvent{
+			Stage:    StageFetch,
+			Playlist: Playlist{ID: FileSourceID(path), Name: path},
+			Done:     i,
+			Total:    len(lines),
+		})
+		var track *Track
+		name := line.URI
+		if line.URI != "" {
+			track, found = found[0], found[1:]
+		} else {
+			track, err = s.SearchTrackContext(ctx, line.Artist, line.Title)
+			if err != nil {
+				return nil, err
+			}
+			name = line.Title
+			if line.Artist != "" {
+				name = line.Artist + " - " + line.Title
+			}
+		}
+		if track == nil {
+			tracks = append(tracks, PlaylistTrack{Track: Track{Name: name}})
+			continue
+		}
+		tracks = append(tracks, PlaylistTrack{Track: *track})
+	}
+	s.progress(ProgressEvent{
+		Stage:    StageFetch,
+		Playlist: Playlist{ID: FileSourceID(path), Name: path},
+		Done:     len(lines),
+		Total:    len(lines),
+	})
+	return tracks, nil
+}
@@ -0,0 +1,141 @@
+package spotify
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTrackFile(t *testing.T) {
+	t.Run("text", func(t *testing.T) {
+		input := "#EXTM3U\nspotify:track:69kOkLUCkxIZYexIgSG8rq\n\nhttps://open.spotify.com/track/2Foc5Q5nqNiosCNqttzHof\nDaft Punk - Get Lucky\nOne More Time\n"
+		lines, err := ParseTrackFile(strings.NewReader(input), "tracks.txt")
+		assert.NoError(t, err)
+		expected := []FileLine{
+			{URI: "spotify:track:69kOkLUCkxIZYexIgSG8rq"},
+			{URI: "spotify:track:2Foc5Q5nqNiosCNqttzHof"},
+			{Artist: "Daft Punk", Title: "Get Lucky"},
+			{Title: "One More Time"},
+		}
+		assert.Equal(t, expected, lines)
+	})
+
+	t.Run("csv with header", func(t *testing.T) {
+		input := "uri,name,artists\nspotify:track:69kOkLUCkxIZYexIgSG8rq,Song,Artist\n,Get Lucky,\"Daft Punk, Pharrell Williams\"\n"
+		lines, err := ParseTrackFile(strings.NewReader(input), "tracks.csv")
+		assert.NoError(t, err)
+		expected := []FileLine{
+			{URI: "spotify:track:69kOkLUCkxIZYexIgSG8rq"},
+			{Artist: "Daft Punk", Title: "Get Lucky"},
+		}
+		assert.Equal(t, expected, lines)
+	})
+
+	t.Run("csv without header", func(t *testing.T) {
+		input := "spotify:track:69kOkLUCkxIZYexIgSG8rq\nDaft Punk - Get Lucky\n"
+		lines, err := ParseTrackFile(strings.NewReader(input), "tracks.CSV")
+		assert.NoError(t, err)
+		expected := []FileLine{
+			{URI: "spotify:track:69kOkLUCkxIZYexIgSG8rq"},
+			{Artist: "Daft Punk", Title: "Get Lucky"},
+		}
+		assert.Equal(t, expected, lines)
+	})
+}
+
+func TestGetTracksFromFile(t *testing.T) {
+	t.Run("resolves lines with search", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "tracks.txt")
+		err := os.WriteFile(path, []byte("spotify:track:69kOkLUCkxIZYexIgSG8rq\nDaft Punk - Get Lucky\nNobody - Nothing\nspotify:track:0000000000000000000000\n"), 0o644)
+		assert.NoError(t, err)
+		mockClient := &http.Client{
+			Transport: &mockRoundTripper{
+				roundTripFunc: func(req *http.Request) (*http.Response, error) {
+					body := `{"tracks": {"items": []}}`
+					switch req.URL.Path {
+					case "/tracks":
+						assert.Equal(t, "69kOkLUCkxIZYexIgSG8rq,0000000000000000000000", req.URL.Query().Get("ids"))
+						body = `{"tracks": [{"type": "track", "uri": "spotify:track:69kOkLUCkxIZYexIgSG8rq", "name": "Song", "duration_ms": 1000}, null]}`
+					case "/search":
+						if req.URL.Query().Get("q") == "track:Get Lucky artist:Daft Punk" {
+							body = `{"tracks": {"items": [{"type": "track", "uri": "spotify:track:lucky", "name": "Get Lucky"}]}}`
+						}
+					default:
+						t.Fatalf("unexpected request: %s", req.URL)
+					}
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(strings.NewReader(body)),
+					}, nil
+				},
+			},
+		}
+		s := Spotify{
+			Client: mockClient,
+			Token:  "mockToken",
+		}
+		sources, err := s.GetPlaylistTracks([]string{FileSourceID(path)})
+		assert.NoError(t, err)
+		expected := []PlaylistTrack{
+			{Track: Track{Type: "track", URI: "spotify:track:69kOkLUCkxIZYexIgSG8rq", Name: "Song", DurationMS: 1000}},
+			{Track: Track{Type: "track", URI: "spotify:track:lucky", Name: "Get Lucky"}},
+			{Track: Track{Name: "Nobody - Nothing"}},
+			{Track: Track{Name: "spotify:track:0000000000000000000000"}},
+		}
+		assert.Equal(t, expected, sources[0])
+		kept, skipped := SkipUnmergeable(sources, false)
+		assert.Len(t, kept[0], 2)
+		assert.Len(t, skipped, 2)
+		for _, item := range skipped {
+			assert.Equal(t, SkipNotFound, item.Reason)
+		}
+	})
+
+	t.Run("looks up URIs in batches", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "tracks.txt")
+		var lines []string
+		for i := range 120 {
+			lines = append(lines, fmt.Sprintf("spotify:track:%022d", i))
+		}
+		err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644)
+		assert.NoError(t, err)
+		var batches []int
+		mockClient := &http.Client{
+			Transport: &mockRoundTripper{
+				roundTripFunc: func(req *http.Request) (*http.Response, error) {
+					assert.Equal(t, "/tracks", req.URL.Path)
+					var tracks []string
+					for _, id := range strings.Split(req.URL.Query().Get("ids"), ",") {
+						tracks = append(tracks, fmt.Sprintf(`{"type": "track", "uri": "spotify:track:%s"}`, id))
+					}
+					batches = append(batches, len(tracks))
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(strings.NewReader(`{"tracks": [` + strings.Join(tracks, ",") + `]}`)),
+					}, nil
+				},
+			},
+		}
+		s := Spotify{
+			Client: mockClient,
+			Token:  "mockToken",
+		}
+		sources, err := s.GetPlaylistTracks([]string{FileSourceID(path)})
+		assert.NoError(t, err)
+		assert.Equal(t, []int{50, 50, 20}, batches)
+		assert.Len(t, sources[0], 120)
+		assert.Equal(t, lines[119], sources[0][119].Track.URI, "expected tracks in file order")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		s := Spotify{Token: "mockToken"}
+		_, err := s.GetPlaylistTracks([]string{FileSourceID(filepath.Join(t.TempDir(), "missing.txt"))})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,119 @@
+package spotify
+
+import (
+	"strings"
+	"time"
+)
+
+/*
+Filter removes merged tracks by their metadata. Zero values
+disable the corresponding rule. Tracks for which Spotify has no
+release date or added_at are removed by rules that need them.
+AddedAfter and AddedBefore are dates, and both keep tracks added on them.
+*/
+type Filter struct {
+	NoExplicit     bool
+	MinDuration    time.Duration
+	MaxDuration    time.Duration
+	MinYear        int
+	MaxYear        int
+	AddedAfter     time.Time
+	AddedBefore    time.Time
+	Artists        []string
+	ExcludeArtists []string
+}
+
+// FilterResult is the number of tracks removed by a filter rule.
+type FilterResult struct {
+	Rule    string `json:"rule"`
+	Removed int    `json:"removed"`
+}
+
+type filterRule struct {
+	name string
+	keep func(p PlaylistTrack) bool
+}
+
+func (f Filter) rules() []filterRule {
+	var rules []filterRule
+	if f.NoExplicit {
+		rules = append(rules, filterRule{"no-explicit", func(p PlaylistTrack) bool {
+			return !p.Track.Explicit
+		}})
+	}
+	if f.MinDuration > 0 {
+		rules = append(rules, filterRule{"min-duration", func(p PlaylistTrack) bool {
+			return p.Track.Duration() >= f.MinDuration
+		}})
+	}
+	if f.MaxDuration > 0 {
+		rules = append(rules, filterRule{"max-duration", func(p PlaylistTrack) bool {
+			return p.Track.Duration() <= f.MaxDuration
+		}})
+	}
+	if f.MinYear > 0 {
+		rules = append(rules, filterRule{"min-year", func(p PlaylistTrack) bool {
+			return p.Track.ReleaseYear() != 0 && p.Track.ReleaseYear() >= f.MinYear
+		}})
+	}
+	if f.MaxYear > 0 {
+		rules = append(rules, filterRule{"max-year", func(p PlaylistTrack) bool {
+			return p.Track.ReleaseYear() != 0 && p.Track.ReleaseYear() <= f.MaxYear
+		}})
+	}
+	if !f.AddedAfter.IsZero() {
+		rules = append(rules, filterRule{"added-after", func(p PlaylistTrack) bool {
+			return !p.AddedAt.Before(f.AddedAfter)
+		}})
+	}
+	if !f.AddedBefore.IsZero() {
+		rules = append(rules, filterRule{"added-before", func(p PlaylistTrack) bool {
+			return !p.AddedAt.IsZero() && p.AddedAt.Before(f.AddedBefore.AddDate(0, 0, 1))
+		}})
+	}
+	if len(f.Artists) > 0 {
+		rules = append(rules, filterRule{"artists", func(p PlaylistTrack) bool {
+			return hasArtist(p.Track, f.Artists)
+		}})
+	}
+	if len(f.ExcludeArtists) > 0 {
+		rules = append(rules, filterRule{"exclude-artists", func(p PlaylistTrack) bool {
+			return !hasArtist(p.Track, f.ExcludeArtists)
+		}})
+	}
+	return rules
+}
+
+/*
+Apply returns the tracks kept by every rule of the filter, along with
+how many tracks each enabled rule removed, in the order they ran.
+*/
+func (f Filter) Apply(tracks []PlaylistTrack) ([]PlaylistTrack, []FilterResult) {
+	var results []FilterResult
+	for _, rule := range f.rules() {
+		var kept []PlaylistTrack
+		for _, p := range tracks {
+			if rule.keep(p) {
+				kept = append(kept, p)
+			}
+		}
+		results = append(results, FilterResult{
+			Rule:    rule.name,
+			Removed: len(tracks) - len(kept),
+		})
+		tracks = kept
+	}
+	return tracks, results
+}
+
+// hasArtist reports whether any of the track's artists is in names, ignoring case.
+func hasArtist(track Track, names []string) bool {
+	for _, artist := range track.Artists {
+		for _, name := range names {
+			if strings.EqualFold(artist.Name, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
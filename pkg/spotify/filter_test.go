@@ -0,0 +1,73 @@
+package spotify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterApply(t *testing.T) {
+	jan := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	jun := time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)
+	tracks := []PlaylistTrack{
+		{AddedAt: jan, Track: Track{URI: "1", Explicit: true, DurationMS: 200000, Album: Album{ReleaseDate: "2001"}, Artists: []Artist{{Name: "A"}}}},
+		{AddedAt: jan, Track: Track{URI: "2", DurationMS: 60000, Album: Album{ReleaseDate: "2005-03"}, Artists: []Artist{{Name: "B"}}}},
+		{AddedAt: jun, Track: Track{URI: "3", DurationMS: 200000, Album: Album{ReleaseDate: "1985-01-01"}, Artists: []Artist{{Name: "A"}}}},
+		{AddedAt: jun, Track: Track{URI: "4", DurationMS: 200000, Album: Album{ReleaseDate: "2010"}, Artists: []Artist{{Name: "C"}, {Name: "A"}}}},
+		{Track: Track{URI: "5", DurationMS: 200000, IsLocal: true, Artists: []Artist{{Name: "A"}}}},
+	}
+
+	t.Run("no rules", func(t *testing.T) {
+		kept, results := Filter{}.Apply(tracks)
+		assert.Equal(t, tracks, kept)
+		assert.Empty(t, results)
+	})
+
+	t.Run("happy path", func(t *testing.T) {
+		f := Filter{
+			NoExplicit:  true,
+			MinDuration: 90 * time.Second,
+			MinYear:     1990,
+		}
+		kept, results := f.Apply(tracks)
+		assert.Equal(t, []string{"4"}, TrackURIs(kept))
+		expected := []FilterResult{
+			{Rule: "no-explicit", Removed: 1},
+			{Rule: "min-duration", Removed: 1},
+			{Rule: "min-year", Removed: 2},
+		}
+		assert.Equal(t, expected, results)
+	})
+
+	t.Run("added dates", func(t *testing.T) {
+		f := Filter{
+			AddedAfter:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			AddedBefore: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		}
+		kept, _ := f.Apply(tracks)
+		assert.Equal(t, []string{"1", "2"}, TrackURIs(kept))
+	})
+
+	t.Run("added on the boundary days", func(t *testing.T) {
+		day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+		tracks := []PlaylistTrack{
+			{AddedAt: day.Add(-time.Second), Track: Track{URI: "before"}},
+			{AddedAt: day, Track: Track{URI: "start"}},
+			{AddedAt: day.Add(23 * time.Hour), Track: Track{URI: "end"}},
+			{AddedAt: day.AddDate(0, 0, 1), Track: Track{URI: "after"}},
+		}
+		kept, _ := Filter{AddedAfter: day, AddedBefore: day}.Apply(tracks)
+		assert.Equal(t, []string{"start", "end"}, TrackURIs(kept))
+	})
+
+	t.Run("artists", func(t *testing.T) {
+		kept, _ := Filter{Artists: []string{"a"}, ExcludeArtists: []string{"C"}}.Apply(tracks)
+		assert.Equal(t, []string{"1", "3", "5"}, TrackURIs(kept))
+	})
+
+	t.Run("max duration and year", func(t *testing.T) {
+		kept, _ := Filter{MaxDuration: 2 * time.Minute, MaxYear: 2008}.Apply(tracks)
+		assert.Equal(t, []string{"2"}, TrackURIs(kept))
+	})
+}
@@ -0,0 +1,166 @@
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+/*
+Journal records the playlists a run creates and the batches of tracks
+already added to each, so a run that fails halfway can be resumed.
+*/
+type Journal struct {
+	CreatedAt time.Time     `json:"created_at"`
+	UserID    string        `json:"user_id"`
+	BatchSize int           `json:"batch_size"`
+	Parts     []JournalPart `json:"parts"`
+	path      string
+}
+
+// JournalPart is a playlist written by a run.
+type JournalPart struct {
+	Name string `json:"name"`
+	// PlaylistID is empty until the playlist is created.
+	PlaylistID  string   `json:"playlist_id,omitempty"`
+	TrackIDs    []string `json:"track_ids"`
+	BatchesDone int      `json:"batches_done"`
+}
+
+// Batches returns the number of batches the tracks of the part are added in.
+func (p JournalPart) Batches(batchSize int) int {
+	return len(chunks(p.TrackIDs, batchSize))
+}
+
+// NewJournal returns an empty journal that is saved to path.
+func NewJournal(path string, userID string, batchSize int) *Journal {
+	return &Journal{
+		CreatedAt: time.Now().UTC(),
+		UserID:    userID,
+		BatchSize: batchSize,
+		path:      path,
+	}
+}
+
+// ReadJournal reads a journal saved to path.
+func ReadJournal(path string) (*Journal, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	var journal Journal
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal journal: %w", err)
+	}
+	journal.path = path
+	return &journal, nil
+}
+
+// Save writes the journal to its path.
+func (j *Journal) Save() error {
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create journal directory: %w", err)
+	}
+	data, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(j.path, data); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes the journal once its run completed.
+func (j *Journal) Remove() error {
+	if err := os.Remove(j.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove journal: %w", err)
+	}
+	return nil
+}
+
+/*
+RunJournal creates the playlists of the journal that do not exist yet
+and adds the batches of tracks that were not added yet, saving the
+journal after each step. It returns the ID of each playlist.
+*/
+func (s *Spotify) RunJournal(j *Journal) ([]string, error) {
+	return s.RunJournalContext(context.Background(), j)
+}
+
+// RunJournalContext is like RunJournal but includes a context.
+func (s *Spotify) RunJournalContext(ctx context.Context, j *Journal) ([]string, error) {
+	if err := j.Save(); err != nil {
+		return nil, err
+	}
+	var playlistIDs []string
+	for i := range j.Parts {
+		part := &j.Parts[i]
+		if part.PlaylistID == "" {
+			playlistID, err := s.CreatePlaylistWithNameContext(ctx, j.UserID, part.Name, part.TrackIDs)
+			if err != nil {
+				return nil, err
+			}
+			part.PlaylistID = playlistID
+			if err := j.Save(); err != nil {
+				return nil, err
+			}
+		}
+		batches := chunks(part.TrackIDs, j.BatchSize)
+		for part.BatchesDone < len(batches) {
+			if _, err := s.addTracks(ctx, part.PlaylistID, batches[part.BatchesDone]); err != nil {
+				return nil, fmt.Errorf(
+					"batch %d of %d: %w",
+					part.BatchesDone+1,
+					len(batches),
+					err,
+				)
+			}
+			part.BatchesDone++
+			if err := j.Save(); err != nil {
+				return nil, err
+			}
+			s.progress(ProgressEvent{
+				Stage:    StageAdd,
+				Playlist: Playlist{ID: part.PlaylistID, Name: part.Name},
+				Done:     part.BatchesDone,
+				Total:    len(batches),
+			})
+		}
+		playlistIDs = append(playlistIDs, part.PlaylistID)
+	}
+	return playlistIDs, nil
+}
+
+/*
+RollbackJournal unfollows the playlists the journal created and
+returns their parts. Unfollowed parts are reset in the journal, so
+it can still be resumed if unfollowing a later playlist fails.
+*/
+func (s *Spotify) RollbackJournal(j *Journal) ([]JournalPart, error) {
+	return s.RollbackJournalContext(context.Background(), j)
+}
+
+// RollbackJournalContext is like RollbackJournal but includes a context.
+func (s *Spotify) RollbackJournalContext(ctx context.Context, j *Journal) ([]JournalPart, error) {
+	var removed []JournalPart
+	for i := range j.Parts {
+		part := &j.Parts[i]
+		if part.PlaylistID == "" {
+			continue
+		}
+		if err := s.UnfollowPlaylistContext(ctx, part.PlaylistID); err != nil {
+			return removed, err
+		}
+		removed = append(removed, *part)
+		part.PlaylistID = ""
+		part.BatchesDone = 0
+		if err := j.Save(); err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
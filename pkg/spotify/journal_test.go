@@ -0,0 +1,115 @@
+package spotify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunJournal(t *testing.T) {
+	t.Run("resumes at the failed batch", func(t *testing.T) {
+		var added [][]string
+		var created int
+		fail := true
+		mockClient := &http.Client{
+			Transport: &mockRoundTripper{
+				roundTripFunc: func(req *http.Request) (*http.Response, error) {
+					if req.URL.Path == "/users/mockUserID/playlists" {
+						created++
+						return &http.Response{
+							StatusCode: http.StatusCreated,
+							Body:       io.NopCloser(strings.NewReader(`{"id": "p2"}`)),
+						}, nil
+					}
+					var body struct {
+						URIs []string `json:"uris"`
+					}
+					if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+						t.Fatalf("failed to decode request body: %v", err)
+					}
+					if req.URL.Path == "/playlists/p2/tracks" && fail {
+						fail = false
+						return &http.Response{
+							StatusCode: http.StatusTooManyRequests,
+							Body:       io.NopCloser(strings.NewReader("")),
+						}, nil
+					}
+					added = append(added, body.URIs)
+					return &http.Response{
+						StatusCode: http.StatusCreated,
+						Body:       io.NopCloser(strings.NewReader(`{"snapshot_id": "snap"}`)),
+					}, nil
+				},
+			},
+		}
+		s := Spotify{
+			Client: mockClient,
+			Token:  "mockToken",
+		}
+		path := filepath.Join(t.TempDir(), "journal.json")
+		j := NewJournal(path, "mockUserID", 2)
+		j.Parts = []JournalPart{
+			{Name: "Part 1", PlaylistID: "p1", TrackIDs: []string{"1", "2", "3"}, BatchesDone: 1},
+			{Name: "Part 2", TrackIDs: []string{"4", "5", "6"}},
+		}
+
+		_, err := s.RunJournal(j)
+		assert.Error(t, err)
+		saved, err := ReadJournal(path)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, saved.Parts[0].BatchesDone)
+		assert.Equal(t, "p2", saved.Parts[1].PlaylistID)
+		assert.Equal(t, 0, saved.Parts[1].BatchesDone)
+
+		playlistIDs, err := s.RunJournal(saved)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"p1", "p2"}, playlistIDs)
+		assert.Equal(t, 1, created, "expected the created playlist to be reused")
+		assert.Equal(t, [][]string{{"3"}, {"4", "5"}, {"6"}}, added)
+
+		assert.NoError(t, saved.Remove())
+		_, err = os.Stat(path)
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestRollbackJournal(t *testing.T) {
+	t.Run("unfollows created playlists", func(t *testing.T) {
+		var unfollowed []string
+		mockClient := &http.Client{
+			Transport: &mockRoundTripper{
+				roundTripFunc: func(req *http.Request) (*http.Response, error) {
+					assert.Equal(t, "DELETE", req.Method)
+					unfollowed = append(unfollowed, req.URL.Path)
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(strings.NewReader("")),
+					}, nil
+				},
+			},
+		}
+		s := Spotify{
+			Client: mockClient,
+			Token:  "mockToken",
+		}
+		j := NewJournal(filepath.Join(t.TempDir(), "journal.json"), "mockUserID", 2)
+		j.Parts = []JournalPart{
+			{Name: "Part 1", PlaylistID: "p1", TrackIDs: []string{"1", "2", "3"}, BatchesDone: 2},
+			{Name: "Part 2", TrackIDs: []string{"4"}},
+		}
+		removed, err := s.RollbackJournal(j)
+		assert.NoError(t, err)
+		expected := []JournalPart{
+			{Name: "Part 1", PlaylistID: "p1", TrackIDs: []string{"1", "2", "3"}, BatchesDone: 2},
+		}
+		assert.Equal(t, expected, removed)
+		assert.Equal(t, []string{"/playlists/p1/followers"}, unfollowed)
+		assert.Empty(t, j.Parts[0].PlaylistID, "expected the unfollowed part to be reset")
+	})
+}
@@ -0,0 +1,101 @@
+package spotify
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// Order is the order in which merged tracks are added to the playlist.
+type Order string
+
+const (
+	OrderSource      Order = "source"
+	OrderInterleave  Order = "interleave"
+	OrderShuffle     Order = "shuffle"
+	OrderAddedAt     Order = "added-at"
+	OrderReleaseDate Order = "release-date"
+)
+
+/*
+Sort reorders the merged tracks. sources holds the tracks of each
+playlist, which interleave uses to take one track from each playlist
+in turn. seed makes shuffle reproducible.
+*/
+func Sort(
+	order Order,
+	sources [][]PlaylistTrack,
+	tracks []PlaylistTrack,
+	seed int64,
+) ([]PlaylistTrack, error) {
+	result := make([]PlaylistTrack, len(tracks))
+	copy(result, tracks)
+	switch order {
+	case OrderSource, "":
+		// Tracks are already in playlist order.
+	case OrderInterleave:
+		result = interleave(sources, tracks)
+	case OrderShuffle:
+		r := rand.New(rand.NewSource(seed))
+		r.Shuffle(len(result), func(i, j int) {
+			result[i], result[j] = result[j], result[i]
+		})
+	case OrderAddedAt:
+		// Tracks without an added date, e.g. from file sources, go last.
+		sort.SliceStable(result, func(i, j int) bool {
+			a, b := result[i].AddedAt, result[j].AddedAt
+			if a.IsZero() || b.IsZero() {
+				return !a.IsZero() && b.IsZero()
+			}
+			return a.Before(b)
+		})
+	case OrderReleaseDate:
+		/*
+			Release dates are "YYYY", "YYYY-MM" or "YYYY-MM-DD"
+			depending on their precision, so they sort as strings.
+			Tracks without a release date, e.g. local files, go last.
+		*/
+		sort.SliceStable(result, func(i, j int) bool {
+			a, b := result[i].Track.Album.ReleaseDate, result[j].Track.Album.ReleaseDate
+			if a == "" || b == "" {
+				return a != "" && b == ""
+			}
+			return a < b
+		})
+	default:
+		return nil, fmt.Errorf("unknown order: %s", order)
+	}
+	return result, nil
+}
+
+/*
+interleave takes one track from each playlist in turn, skipping
+tracks that were already taken or are not in tracks.
+*/
+func interleave(sources [][]PlaylistTrack, tracks []PlaylistTrack) []PlaylistTrack {
+	remaining := make(map[string]PlaylistTrack)
+	for _, p := range tracks {
+		remaining[p.Track.URI] = p
+	}
+	positions := make([]int, len(sources))
+	var result []PlaylistTrack
+	for len(remaining) > 0 {
+		progressed := false
+		for i, playlistTracks := range sources {
+			for positions[i] < len(playlistTracks) {
+				uri := playlistTracks[positions[i]].Track.URI
+				positions[i]++
+				if p, ok := remaining[uri]; ok {
+					result = append(result, p)
+					delete(remaining, uri)
+					progressed = true
+					break
+				}
+			}
+		}
+		if !progressed {
+			break
+		}
+	}
+	return result
+}
@@ -0,0 +1,80 @@
+package spotify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSort(t *testing.T) {
+	sources := [][]PlaylistTrack{
+		tracksFromURIs("a1", "a2", "a3"),
+		tracksFromURIs("b1", "a2", "b2"),
+	}
+	tracks := Union(sources)
+
+	t.Run("source", func(t *testing.T) {
+		sorted, err := Sort(OrderSource, sources, tracks, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a1", "a2", "a3", "b1", "b2"}, TrackURIs(sorted))
+	})
+
+	t.Run("interleave", func(t *testing.T) {
+		sorted, err := Sort(OrderInterleave, sources, tracks, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a1", "b1", "a2", "b2", "a3"}, TrackURIs(sorted))
+	})
+
+	t.Run("shuffle is reproducible", func(t *testing.T) {
+		first, err := Sort(OrderShuffle, sources, tracks, 42)
+		assert.NoError(t, err)
+		second, err := Sort(OrderShuffle, sources, tracks, 42)
+		assert.NoError(t, err)
+		assert.Equal(t, first, second)
+		assert.ElementsMatch(t, TrackURIs(tracks), TrackURIs(first))
+		assert.Equal(t, []string{"a1", "a2", "a3", "b1", "b2"}, TrackURIs(tracks), "input was modified")
+	})
+
+	t.Run("added-at", func(t *testing.T) {
+		now := time.Now()
+		tracks := []PlaylistTrack{
+			{AddedAt: now, Track: Track{URI: "1"}},
+			{AddedAt: now.Add(-time.Hour), Track: Track{URI: "2"}},
+			{AddedAt: now.Add(-2 * time.Hour), Track: Track{URI: "3"}},
+		}
+		sorted, err := Sort(OrderAddedAt, nil, tracks, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"3", "2", "1"}, TrackURIs(sorted))
+	})
+
+	t.Run("added-at without added dates", func(t *testing.T) {
+		now := time.Now()
+		tracks := []PlaylistTrack{
+			{Track: Track{URI: "1"}},
+			{AddedAt: now, Track: Track{URI: "2"}},
+			{Track: Track{URI: "3"}},
+			{AddedAt: now.Add(-time.Hour), Track: Track{URI: "4"}},
+		}
+		sorted, err := Sort(OrderAddedAt, nil, tracks, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"4", "2", "1", "3"}, TrackURIs(sorted))
+	})
+
+	t.Run("release-date", func(t *testing.T) {
+		tracks := []PlaylistTrack{
+			{Track: Track{URI: "1", Album: Album{ReleaseDate: "2001-05-01"}}},
+			{Track: Track{URI: "2"}},
+			{Track: Track{URI: "3", Album: Album{ReleaseDate: "1999"}}},
+			{Track: Track{URI: "4", Album: Album{ReleaseDate: "2001-04"}}},
+		}
+		sorted, err := Sort(OrderReleaseDate, nil, tracks, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"3", "4", "1", "2"}, TrackURIs(sorted))
+	})
+
+	t.Run("unknown order", func(t *testing.T) {
+		_, err := Sort("alphabetical", sources, tracks, 0)
+		assert.Error(t, err, "expected error for unknown order")
+	})
+}
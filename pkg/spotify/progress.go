@@ -0,0 +1,43 @@
+package spotify
+
+import "time"
+
+// ProgressStage is the kind of work a ProgressEvent reports on.
+type ProgressStage string
+
+const (
+	// StageFetch reports a page of playlist items or a file source line read.
+	StageFetch ProgressStage = "fetch"
+	// StageAdd reports a batch of tracks added to a playlist.
+	StageAdd ProgressStage = "add"
+	// StageRemove reports a batch of tracks removed from a playlist.
+	StageRemove ProgressStage = "remove"
+	// StageRetry reports a failed request that is sent again after Delay.
+	StageRetry ProgressStage = "retry"
+)
+
+/*
+ProgressEvent reports how far the current request loop got. Done
+equals Total once the playlist is fetched or all batches are sent.
+*/
+type ProgressEvent struct {
+	Stage ProgressStage
+	// Playlist has a name if the caller passed one.
+	Playlist Playlist
+	// Pages is the number of pages fetched so far.
+	Pages int
+	// Done and Total count items for StageFetch and batches otherwise.
+	Done   int
+	Total  int
+	Cached bool
+	// Attempt, Delay and Err are only set for StageRetry.
+	Attempt int
+	Delay   time.Duration
+	Err     error
+}
+
+func (s *Spotify) progress(event ProgressEvent) {
+	if s.Progress != nil {
+		s.Progress(event)
+	}
+}
@@ -0,0 +1,96 @@
+package spotify
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgress(t *testing.T) {
+	t.Run("reports pages fetched", func(t *testing.T) {
+		mockClient := &http.Client{
+			Transport: &mockRoundTripper{
+				roundTripFunc: func(req *http.Request) (*http.Response, error) {
+					mockResponse := `{"items": [{"track": {"uri": "111"}}], "next": null, "total": 3}`
+					if req.URL.Path == "/playlists/p1/tracks" {
+						mockResponse = `{"items": [{"track": {"uri": "123"}}, {"track": {"uri": "456"}}], "next": "https://api.spotify.com/v1/next?offset=2", "total": 3}`
+					}
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(strings.NewReader(mockResponse)),
+					}, nil
+				},
+			},
+		}
+		var events []ProgressEvent
+		s := Spotify{
+			Client:   mockClient,
+			Token:    "mockToken",
+			Progress: func(event ProgressEvent) { events = append(events, event) },
+		}
+		playlist := Playlist{ID: "p1", Name: "Playlist 1"}
+		_, err := s.GetTracks([]Playlist{playlist})
+		assert.NoError(t, err)
+		expected := []ProgressEvent{
+			{Stage: StageFetch, Playlist: playlist, Pages: 1, Done: 2, Total: 3},
+			{Stage: StageFetch, Playlist: playlist, Pages: 2, Done: 3, Total: 3},
+		}
+		assert.Equal(t, expected, events)
+	})
+
+	t.Run("reports batches added", func(t *testing.T) {
+		mockClient := &http.Client{
+			Transport: &mockRoundTripper{
+				roundTripFunc: func(req *http.Request) (*http.Response, error) {
+					return &http.Response{
+						StatusCode: http.StatusCreated,
+						Body:       io.NopCloser(strings.NewReader(`{"snapshot_id": "snap"}`)),
+					}, nil
+				},
+			},
+		}
+		var events []ProgressEvent
+		s := Spotify{
+			Client:   mockClient,
+			Token:    "mockToken",
+			Progress: func(event ProgressEvent) { events = append(events, event) },
+		}
+		_, err := s.AddTracksToPlaylist("p1", []string{"1", "2", "3"}, 2)
+		assert.NoError(t, err)
+		expected := []ProgressEvent{
+			{Stage: StageAdd, Playlist: Playlist{ID: "p1"}, Done: 1, Total: 2},
+			{Stage: StageAdd, Playlist: Playlist{ID: "p1"}, Done: 2, Total: 2},
+		}
+		assert.Equal(t, expected, events)
+	})
+
+	t.Run("reports batches of items removed", func(t *testing.T) {
+		mockClient := &http.Client{
+			Transport: &mockRoundTripper{
+				roundTripFunc: func(req *http.Request) (*http.Response, error) {
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(strings.NewReader(`{"snapshot_id": "snap"}`)),
+					}, nil
+				},
+			},
+		}
+		var events []ProgressEvent
+		s := Spotify{
+			Client:   mockClient,
+			Token:    "mockToken",
+			Progress: func(event ProgressEvent) { events = append(events, event) },
+		}
+		items := []ItemPosition{{URI: "1", Position: 1}, {URI: "2", Position: 3}, {URI: "3", Position: 5}}
+		_, err := s.RemovePlaylistItems("p1", "snap", items, 2)
+		assert.NoError(t, err)
+		expected := []ProgressEvent{
+			{Stage: StageRemove, Playlist: Playlist{ID: "p1"}, Done: 1, Total: 2},
+			{Stage: StageRemove, Playlist: Playlist{ID: "p1"}, Done: 2, Total: 2},
+		}
+		assert.Equal(t, expected, events)
+	})
+}
@@ -0,0 +1,57 @@
+package spotify
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Spotify IDs are 22 character base62 strings.
+var idPattern = regexp.MustCompile(`^[0-9A-Za-z]{22}$`)
+
+/*
+ParsePlaylistID extracts the playlist ID from a playlist URL
+(https://open.spotify.com/playlist/...), URI (spotify:playlist:...)
+or bare ID. It reports false if ref is none of these.
+*/
+func ParsePlaylistID(ref string) (string, bool) {
+	return parseID(ref, "playlist")
+}
+
+/*
+ParseTrackURI returns the URI of the track referenced by a track URL
+(https://open.spotify.com/track/...), URI (spotify:track:...) or bare
+ID. It reports false if ref is none of these.
+*/
+func ParseTrackURI(ref string) (string, bool) {
+	id, ok := parseID(ref, "track")
+	if !ok {
+		return "", false
+	}
+	return "spotify:track:" + id, true
+}
+
+// parseID extracts the ID of a Spotify object of the given kind from ref.
+func parseID(ref, kind string) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if id, found := strings.CutPrefix(ref, "spotify:"+kind+":"); found {
+		ref = id
+	}
+	if u, err := url.Parse(ref); err == nil && u.Host == "open.spotify.com" {
+		/*
+			Shared links may include a locale
+			prefix, e.g. /intl-de/playlist/<id>.
+		*/
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		for i := 0; i < len(parts)-1; i++ {
+			if parts[i] == kind && idPattern.MatchString(parts[i+1]) {
+				return parts[i+1], true
+			}
+		}
+		return "", false
+	}
+	if idPattern.MatchString(ref) {
+		return ref, true
+	}
+	return "", false
+}
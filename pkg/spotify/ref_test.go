@@ -0,0 +1,52 @@
+package spotify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePlaylistID(t *testing.T) {
+	tests := []struct {
+		ref      string
+		expected string
+		ok       bool
+	}{
+		{"https://open.spotify.com/playlist/2pyrktawyLSFKVIYd01cjP", "2pyrktawyLSFKVIYd01cjP", true},
+		{"https://open.spotify.com/playlist/2pyrktawyLSFKVIYd01cjP?si=abc123", "2pyrktawyLSFKVIYd01cjP", true},
+		{"https://open.spotify.com/intl-de/playlist/2pyrktawyLSFKVIYd01cjP", "2pyrktawyLSFKVIYd01cjP", true},
+		{"spotify:playlist:2pyrktawyLSFKVIYd01cjP", "2pyrktawyLSFKVIYd01cjP", true},
+		{"2pyrktawyLSFKVIYd01cjP", "2pyrktawyLSFKVIYd01cjP", true},
+		{"https://open.spotify.com/album/2pyrktawyLSFKVIYd01cjP", "", false},
+		{"spotify:playlist:foo", "", false},
+		{"Road Trip", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			id, ok := ParsePlaylistID(tt.ref)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, id)
+		})
+	}
+}
+
+func TestParseTrackURI(t *testing.T) {
+	tests := []struct {
+		ref      string
+		expected string
+		ok       bool
+	}{
+		{"https://open.spotify.com/track/69kOkLUCkxIZYexIgSG8rq?si=abc", "spotify:track:69kOkLUCkxIZYexIgSG8rq", true},
+		{"spotify:track:69kOkLUCkxIZYexIgSG8rq", "spotify:track:69kOkLUCkxIZYexIgSG8rq", true},
+		{"69kOkLUCkxIZYexIgSG8rq", "spotify:track:69kOkLUCkxIZYexIgSG8rq", true},
+		{"spotify:playlist:69kOkLUCkxIZYexIgSG8rq", "", false},
+		{"Daft Punk - Get Lucky", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			uri, ok := ParseTrackURI(tt.ref)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, uri)
+		})
+	}
+}
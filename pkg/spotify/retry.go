@@ -0,0 +1,104 @@
+package spotify
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+/*
+RetryPolicy controls how requests that were rate limited or failed
+with a server or network error are sent again. Delays between attempts
+grow exponentially with jitter, unless Spotify asks for a delay with
+a Retry-After header.
+*/
+type RetryPolicy struct {
+	// MaxAttempts is the number of times a request is sent, including the first.
+	MaxAttempts int
+	// Budget caps the total time spent waiting between the attempts of a request.
+	Budget time.Duration
+	// BaseDelay is the delay before the first retry, doubled for each retry after it.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts when there is no Retry-After.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy waits up to 2 minutes for a request to go through.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	Budget:      2 * time.Minute,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+// backoff returns the delay before retry n (starting at 1), with jitter.
+func (p *RetryPolicy) backoff(n int) time.Duration {
+	delay := p.MaxDelay
+	if shift := n - 1; shift < 30 && p.BaseDelay<<shift < p.MaxDelay {
+		delay = p.BaseDelay << shift
+	}
+	if delay <= 0 {
+		return 0
+	}
+	// Spreads out retries of requests that failed at the same time.
+	return delay/2 + rand.N(delay/2+1)
+}
+
+/*
+delay returns how long to wait before sending a request again that
+failed with err on the given attempt, and false if it is not retried.
+*/
+func (p *RetryPolicy) delay(method string, attempt int, waited time.Duration, err error) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts || !retryable(method, err) {
+		return 0, false
+	}
+	delay := p.backoff(attempt)
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) && reqErr.RetryAfter > 0 {
+		delay = reqErr.RetryAfter
+	}
+	if waited+delay > p.Budget {
+		return 0, false
+	}
+	return delay, true
+}
+
+/*
+retryable reports whether a request that failed with err can be sent
+again. Adding tracks is not idempotent, so POST requests are only
+retried if Spotify did not process them: when they were rate limited
+or never reached the server.
+*/
+func retryable(method string, err error) bool {
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		if reqErr.StatusCode == http.StatusTooManyRequests {
+			return true
+		}
+		return reqErr.StatusCode >= 500 && method != "POST"
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && method != "POST"
+}
+
+/*
+parseRetryAfter parses a Retry-After header, which is either a number
+of seconds or an HTTP date. It returns 0 if the header is not set.
+*/
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,178 @@
+package spotify
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	Budget:      time.Second,
+	BaseDelay:   time.Millisecond,
+	MaxDelay:    time.Millisecond,
+}
+
+func TestRetry(t *testing.T) {
+	newSpotify := func(responses ...func(req *http.Request) (*http.Response, error)) (*Spotify, *int) {
+		requests := 0
+		mockClient := &http.Client{
+			Transport: &mockRoundTripper{
+				roundTripFunc: func(req *http.Request) (*http.Response, error) {
+					response := responses[min(requests, len(responses)-1)]
+					requests++
+					return response(req)
+				},
+			},
+		}
+		policy := testRetryPolicy
+		return &Spotify{Client: mockClient, Token: "mockToken", Retry: &policy}, &requests
+	}
+	status := func(code int, header http.Header) func(req *http.Request) (*http.Response, error) {
+		return func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: code,
+				Header:     header,
+				Body:       io.NopCloser(strings.NewReader(`{"id": "mockID"}`)),
+			}, nil
+		}
+	}
+	fail := func(err error) func(req *http.Request) (*http.Response, error) {
+		return func(req *http.Request) (*http.Response, error) {
+			return nil, err
+		}
+	}
+
+	t.Run("retries rate limited and failed requests", func(t *testing.T) {
+		s, requests := newSpotify(
+			status(http.StatusTooManyRequests, http.Header{"Retry-After": {"0"}}),
+			status(http.StatusBadGateway, nil),
+			status(http.StatusOK, nil),
+		)
+		var attempts []int
+		s.Progress = func(event ProgressEvent) {
+			assert.Equal(t, StageRetry, event.Stage)
+			attempts = append(attempts, event.Attempt)
+		}
+		userID, err := s.GetUserID()
+		assert.NoError(t, err)
+		assert.Equal(t, "mockID", userID)
+		assert.Equal(t, 3, *requests)
+		assert.Equal(t, []int{2, 3}, attempts)
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		s, requests := newSpotify(status(http.StatusServiceUnavailable, nil))
+		_, err := s.GetUserID()
+		var reqErr *RequestError
+		assert.ErrorAs(t, err, &reqErr)
+		assert.Equal(t, http.StatusServiceUnavailable, reqErr.StatusCode)
+		assert.Equal(t, 3, *requests)
+	})
+
+	t.Run("gives up if Retry-After exceeds the budget", func(t *testing.T) {
+		s, requests := newSpotify(status(http.StatusTooManyRequests, http.Header{"Retry-After": {"120"}}))
+		_, err := s.GetUserID()
+		var reqErr *RequestError
+		assert.ErrorAs(t, err, &reqErr)
+		assert.Equal(t, 2*time.Minute, reqErr.RetryAfter)
+		assert.Equal(t, 1, *requests)
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		s, requests := newSpotify(status(http.StatusNotFound, nil))
+		_, err := s.GetUserID()
+		assert.Error(t, err)
+		assert.Equal(t, 1, *requests)
+	})
+
+	t.Run("retries network errors", func(t *testing.T) {
+		s, requests := newSpotify(fail(errors.New("connection reset")), status(http.StatusOK, nil))
+		_, err := s.GetUserID()
+		assert.NoError(t, err)
+		assert.Equal(t, 2, *requests)
+	})
+
+	t.Run("resends rate limited POSTs", func(t *testing.T) {
+		s, requests := newSpotify(
+			status(http.StatusTooManyRequests, nil),
+			status(http.StatusCreated, nil),
+		)
+		_, err := s.AddTracksToPlaylist("mockPlaylistID", []string{"track1"}, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, *requests)
+	})
+
+	t.Run("resends POSTs that never reached the server", func(t *testing.T) {
+		s, requests := newSpotify(
+			fail(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}),
+			status(http.StatusCreated, nil),
+		)
+		_, err := s.AddTracksToPlaylist("mockPlaylistID", []string{"track1"}, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, *requests)
+	})
+
+	t.Run("does not resend POSTs that may have been processed", func(t *testing.T) {
+		for _, response := range []func(req *http.Request) (*http.Response, error){
+			status(http.StatusInternalServerError, nil),
+			fail(errors.New("connection reset")),
+		} {
+			s, requests := newSpotify(response, status(http.StatusCreated, nil))
+			_, err := s.AddTracksToPlaylist("mockPlaylistID", []string{"track1"}, 100)
+			assert.Error(t, err)
+			assert.Equal(t, 1, *requests, "expected the tracks to be added at most once")
+		}
+	})
+
+	t.Run("resends the request body", func(t *testing.T) {
+		var bodies []string
+		record := func(next func(req *http.Request) (*http.Response, error)) func(req *http.Request) (*http.Response, error) {
+			return func(req *http.Request) (*http.Response, error) {
+				body, err := io.ReadAll(req.Body)
+				assert.NoError(t, err)
+				bodies = append(bodies, string(body))
+				return next(req)
+			}
+		}
+		s, _ := newSpotify(
+			record(status(http.StatusTooManyRequests, nil)),
+			record(status(http.StatusCreated, nil)),
+		)
+		_, err := s.AddTracksToPlaylist("mockPlaylistID", []string{"track1"}, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{`{"uris":["track1"]}`, `{"uris":["track1"]}`}, bodies)
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"3", 3 * time.Second},
+		{now.Add(10 * time.Second).Format(http.TimeFormat), 10 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"", 0},
+		{"soon", 0},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, parseRetryAfter(test.value, now), test.value)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: time.Second, MaxDelay: 8 * time.Second}
+	for n, limit := range map[int]time.Duration{1: time.Second, 3: 4 * time.Second, 10: 8 * time.Second} {
+		delay := p.backoff(n)
+		assert.GreaterOrEqual(t, delay, limit/2, "retry %d", n)
+		assert.LessOrEqual(t, delay, limit, "retry %d", n)
+	}
+}
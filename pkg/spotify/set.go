@@ -0,0 +1,129 @@
+package spotify
+
+import "fmt"
+
+// Mode is the set operation used to combine the tracks of each playlist.
+type Mode string
+
+const (
+	ModeUnion     Mode = "union"
+	ModeIntersect Mode = "intersect"
+	ModeSubtract  Mode = "subtract"
+	ModeXor       Mode = "xor"
+)
+
+/*
+Combine applies mode to the tracks of each playlist. Tracks that
+dedupe identifies as the same are only included once, using the
+version picked by keep at the position where the track first appears.
+*/
+func Combine(
+	mode Mode,
+	sources [][]PlaylistTrack,
+	dedupe Dedupe,
+	keep Keep,
+) ([]PlaylistTrack, error) {
+	var include func(count int, inFirst bool) bool
+	switch mode {
+	case ModeUnion, "":
+		// Tracks in any playlist.
+		include = func(count int, inFirst bool) bool {
+			return true
+		}
+	case ModeIntersect:
+		// Tracks in every playlist.
+		include = func(count int, inFirst bool) bool {
+			return count == len(sources)
+		}
+	case ModeSubtract:
+		// Tracks in the first playlist and in none of the others.
+		include = func(count int, inFirst bool) bool {
+			return inFirst && count == 1
+		}
+	case ModeXor:
+		// Tracks in exactly one playlist.
+		include = func(count int, inFirst bool) bool {
+			return count == 1
+		}
+	default:
+		return nil, fmt.Errorf("unknown mode: %s", mode)
+	}
+	key, err := dedupe.key()
+	if err != nil {
+		return nil, err
+	}
+	better, err := keep.better()
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	inFirst := make(map[string]bool)
+	for i, playlistTracks := range sources {
+		seen := make(map[string]bool)
+		for _, p := range playlistTracks {
+			k := key(p.Track)
+			if !seen[k] {
+				counts[k]++
+				seen[k] = true
+			}
+			if i == 0 {
+				inFirst[k] = true
+			}
+		}
+	}
+	var result []PlaylistTrack
+	positions := make(map[string]int)
+	for _, playlistTracks := range sources {
+		for _, p := range playlistTracks {
+			k := key(p.Track)
+			if !include(counts[k], inFirst[k]) {
+				continue
+			}
+			if i, exists := positions[k]; exists {
+				if better(p, result[i]) {
+					result[i] = p
+				}
+				continue
+			}
+			positions[k] = len(result)
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+// Union returns the tracks that are in any of the playlists, omitting duplicate URIs.
+func Union(sources [][]PlaylistTrack) []PlaylistTrack {
+	result, _ := Combine(ModeUnion, sources, DedupeURI, KeepFirst)
+	return result
+}
+
+// TrackURIs returns the URI of each track.
+func TrackURIs(tracks []PlaylistTrack) []string {
+	var uris []string
+	for _, p := range tracks {
+		uris = append(uris, p.Track.URI)
+	}
+	return uris
+}
+
+/*
+PlaylistURIs returns the URI each track is stored under in its
+playlist, which is what removing it from the playlist expects.
+*/
+func PlaylistURIs(tracks []PlaylistTrack) []string {
+	var uris []string
+	for _, p := range tracks {
+		uris = append(uris, p.Track.PlaylistURI())
+	}
+	return uris
+}
+
+// CountTracks returns the total number of tracks across the playlists.
+func CountTracks(sources [][]PlaylistTrack) int {
+	total := 0
+	for _, playlistTracks := range sources {
+		total += len(playlistTracks)
+	}
+	return total
+}
@@ -0,0 +1,117 @@
+package spotify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tracksFromURIs(uris ...string) []PlaylistTrack {
+	var tracks []PlaylistTrack
+	for _, uri := range uris {
+		tracks = append(tracks, PlaylistTrack{Track: Track{URI: uri}})
+	}
+	return tracks
+}
+
+func TestCombine(t *testing.T) {
+	sources := [][]PlaylistTrack{
+		tracksFromURIs("1", "2", "3", "3"),
+		tracksFromURIs("2", "3", "4"),
+		tracksFromURIs("3", "5"),
+	}
+	tests := []struct {
+		mode     Mode
+		expected []string
+	}{
+		{ModeUnion, []string{"1", "2", "3", "4", "5"}},
+		{ModeIntersect, []string{"3"}},
+		{ModeSubtract, []string{"1"}},
+		{ModeXor, []string{"1", "4", "5"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			tracks, err := Combine(tt.mode, sources, DedupeURI, KeepFirst)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, TrackURIs(tracks), "unexpected tracks returned")
+		})
+	}
+
+	t.Run("unknown mode", func(t *testing.T) {
+		_, err := Combine("merge", sources, DedupeURI, KeepFirst)
+		assert.Error(t, err, "expected error for unknown mode")
+	})
+
+	t.Run("no playlists", func(t *testing.T) {
+		for _, mode := range []Mode{ModeUnion, ModeIntersect, ModeSubtract, ModeXor} {
+			tracks, err := Combine(mode, nil, DedupeURI, KeepFirst)
+			assert.NoError(t, err)
+			assert.Empty(t, tracks)
+		}
+	})
+}
+
+func TestCombineDedupe(t *testing.T) {
+	single := PlaylistTrack{Track: Track{
+		URI: "single", Name: "Song (feat. B)", Popularity: 40, Artists: []Artist{{Name: "A"}},
+		Album: Album{ReleaseDate: "2010-01-01"}, ExternalIDs: ExternalIDs{ISRC: "X1"},
+	}}
+	album := PlaylistTrack{Track: Track{
+		URI: "album", Name: "Song", Popularity: 70, Artists: []Artist{{Name: "A"}},
+		Album: Album{ReleaseDate: "2010-03-01"}, ExternalIDs: ExternalIDs{ISRC: "X1"},
+	}}
+	remaster := PlaylistTrack{Track: Track{
+		URI: "remaster", Name: "Song - 2020 Remastered", Popularity: 50, Artists: []Artist{{Name: "A"}},
+		Album: Album{ReleaseDate: "2020"}, ExternalIDs: ExternalIDs{ISRC: "X2"},
+	}}
+	other := PlaylistTrack{Track: Track{URI: "other", Name: "Other Song", Artists: []Artist{{Name: "A"}}}}
+	sources := [][]PlaylistTrack{
+		{remaster, other},
+		{album, single},
+	}
+	tests := []struct {
+		name     string
+		dedupe   Dedupe
+		keep     Keep
+		expected []string
+	}{
+		{"uri", DedupeURI, KeepFirst, []string{"remaster", "other", "album", "single"}},
+		{"isrc", DedupeISRC, KeepFirst, []string{"remaster", "other", "album"}},
+		{"isrc keeps earliest", DedupeISRC, KeepEarliest, []string{"remaster", "other", "single"}},
+		{"fuzzy", DedupeFuzzy, KeepFirst, []string{"remaster", "other"}},
+		{"fuzzy keeps most popular", DedupeFuzzy, KeepPopular, []string{"album", "other"}},
+		{"fuzzy keeps earliest", DedupeFuzzy, KeepEarliest, []string{"single", "other"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracks, err := Combine(ModeUnion, sources, tt.dedupe, tt.keep)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, TrackURIs(tracks), "unexpected tracks returned")
+		})
+	}
+
+	t.Run("intersect by isrc", func(t *testing.T) {
+		sources := [][]PlaylistTrack{{single}, {album}}
+		tracks, err := Combine(ModeIntersect, sources, DedupeISRC, KeepFirst)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"single"}, TrackURIs(tracks))
+	})
+
+	t.Run("unknown dedupe", func(t *testing.T) {
+		_, err := Combine(ModeUnion, sources, "title", KeepFirst)
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown keep", func(t *testing.T) {
+		_, err := Combine(ModeUnion, sources, DedupeURI, "last")
+		assert.Error(t, err)
+	})
+}
+
+func TestCountTracks(t *testing.T) {
+	sources := [][]PlaylistTrack{
+		tracksFromURIs("1", "2", "2"),
+		tracksFromURIs("1", "3"),
+	}
+	assert.Equal(t, 5, CountTracks(sources))
+}
@@ -0,0 +1,74 @@
+package spotify
+
+import "strings"
+
+// SkipReason is why a playlist item cannot be merged.
+type SkipReason string
+
+const (
+	// SkipUnavailable is a deleted track, or a track that cannot be played in the user's market.
+	SkipUnavailable SkipReason = "unavailable"
+	// SkipLocal is a local file, which cannot be added to a playlist through the API.
+	SkipLocal SkipReason = "local file"
+	// SkipEpisode is a podcast episode.
+	SkipEpisode SkipReason = "episode"
+	// SkipNotFound is a line of a file source that Spotify could not resolve.
+	SkipNotFound SkipReason = "not found"
+)
+
+// SkippedItem is a playlist item left out of the merge.
+type SkippedItem struct {
+	// Source is the index of the item's playlist.
+	Source int
+	// Position is the index of the item in its playlist.
+	Position int
+	Item     PlaylistTrack
+	Reason   SkipReason
+}
+
+/*
+SkipUnmergeable removes the items of each playlist that would make
+adding tracks fail: deleted or unavailable tracks, local files,
+unresolved lines of file sources and, unless includeEpisodes is set,
+podcast episodes.
+*/
+func SkipUnmergeable(sources [][]PlaylistTrack, includeEpisodes bool) ([][]PlaylistTrack, []SkippedItem) {
+	var result [][]PlaylistTrack
+	var skipped []SkippedItem
+	for i, playlistTracks := range sources {
+		var kept []PlaylistTrack
+		for j, p := range playlistTracks {
+			reason, skip := skipReason(p.Track, includeEpisodes)
+			if skip {
+				skipped = append(skipped, SkippedItem{
+					Source:   i,
+					Position: j,
+					Item:     p,
+					Reason:   reason,
+				})
+				continue
+			}
+			kept = append(kept, p)
+		}
+		result = append(result, kept)
+	}
+	return result, skipped
+}
+
+func skipReason(t Track, includeEpisodes bool) (SkipReason, bool) {
+	switch {
+	case t.IsLocal || strings.HasPrefix(t.URI, "spotify:local:"):
+		return SkipLocal, true
+	// Unresolved lines of file sources have a name but no URI.
+	case t.URI == "" && t.Name != "":
+		return SkipNotFound, true
+	// Deleted tracks are returned as "track": null.
+	case t.URI == "":
+		return SkipUnavailable, true
+	case t.IsPlayable != nil && !*t.IsPlayable:
+		return SkipUnavailable, true
+	case !includeEpisodes && (t.Type == "episode" || strings.HasPrefix(t.URI, "spotify:episode:")):
+		return SkipEpisode, true
+	}
+	return "", false
+}
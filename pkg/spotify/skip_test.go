@@ -0,0 +1,41 @@
+package spotify
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkipUnmergeable(t *testing.T) {
+	var items []PlaylistTrack
+	err := json.Unmarshal([]byte(`[
+		{"track": {"type": "track", "uri": "spotify:track:1"}},
+		{"track": null},
+		{"track": {"type": "track", "uri": "spotify:local:Artist:Album:Song:180", "is_local": true}},
+		{"track": {"type": "episode", "uri": "spotify:episode:1"}},
+		{"track": {"type": "track", "uri": "spotify:track:2", "is_playable": false}},
+		{"track": {"type": "track", "uri": "spotify:track:3", "is_playable": true}}
+	]`), &items)
+	assert.NoError(t, err)
+	sources := [][]PlaylistTrack{items[:3], items[3:]}
+
+	t.Run("skips episodes by default", func(t *testing.T) {
+		kept, skipped := SkipUnmergeable(sources, false)
+		assert.Equal(t, []string{"spotify:track:1"}, TrackURIs(kept[0]))
+		assert.Equal(t, []string{"spotify:track:3"}, TrackURIs(kept[1]))
+		var reasons []SkipReason
+		for _, item := range skipped {
+			reasons = append(reasons, item.Reason)
+		}
+		assert.Equal(t, []SkipReason{SkipUnavailable, SkipLocal, SkipEpisode, SkipUnavailable}, reasons)
+		assert.Equal(t, 1, skipped[2].Source)
+		assert.Equal(t, 0, skipped[2].Position)
+	})
+
+	t.Run("includes episodes", func(t *testing.T) {
+		kept, skipped := SkipUnmergeable(sources, true)
+		assert.Equal(t, []string{"spotify:episode:1", "spotify:track:3"}, TrackURIs(kept[1]))
+		assert.Len(t, skipped, 3)
+	})
+}
@@ -0,0 +1,137 @@
+package spotify
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+/*
+Source is an entry of the playlists array in the user's
+~/.mergify/config.json file. It is either a plain string holding a
+playlist name, URL, URI or ID, an object holding a glob "match" or
+"regex" pattern that is expanded against the user's playlists, or an
+object holding the path of a file listing tracks:
+
+	"Road Trip"
+	{"match": "* 2024"}
+	{"regex": "^Monthly"}
+	{"file": "tracks.csv"}
+*/
+type Source struct {
+	Name  string `json:"name,omitempty"`
+	Match string `json:"match,omitempty"`
+	Regex string `json:"regex,omitempty"`
+	File  string `json:"file,omitempty"`
+}
+
+func (src *Source) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*src = Source{Name: name}
+		return nil
+	}
+	type source Source
+	var raw source
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("invalid playlist entry %s: %w", data, err)
+	}
+	*src = Source(raw)
+	if src.Name == "" && src.Match == "" && src.Regex == "" && src.File == "" {
+		return fmt.Errorf("invalid playlist entry %s: expected name, match, regex or file", data)
+	}
+	if _, err := src.pattern(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// String returns the source as it should appear in messages.
+func (src Source) String() string {
+	switch {
+	case src.Match != "":
+		return src.Match
+	case src.Regex != "":
+		return "/" + src.Regex + "/"
+	case src.File != "":
+		return src.File
+	}
+	return src.Name
+}
+
+// IsPattern reports whether the source is a glob or regex pattern.
+func (src Source) IsPattern() bool {
+	return src.Name == "" && (src.Match != "" || src.Regex != "")
+}
+
+/*
+pattern compiles the source's glob or regex pattern. In globs,
+"*" matches any run of characters and "?" matches one character.
+*/
+func (src Source) pattern() (*regexp.Regexp, error) {
+	expr := src.Regex
+	if src.Match != "" {
+		expr = regexp.QuoteMeta(src.Match)
+		expr = strings.ReplaceAll(expr, `\*`, ".*")
+		expr = strings.ReplaceAll(expr, `\?`, ".")
+		expr = "^" + expr + "$"
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %s: %w", src, err)
+	}
+	return re, nil
+}
+
+/*
+expand returns the playlists whose names match the pattern of
+include and none of the patterns in exclude, where plain names
+in exclude are treated as globs. Matches are ordered
+by name, then ID, so the result does not depend on library order.
+*/
+func expand(playlists []Playlist, include Source, exclude []Source) ([]Playlist, error) {
+	re, err := include.pattern()
+	if err != nil {
+		return nil, err
+	}
+	var excludeRes []*regexp.Regexp
+	for _, src := range exclude {
+		// An empty pattern would exclude every playlist.
+		if src.File != "" || (src.Name == "" && !src.IsPattern()) {
+			return nil, fmt.Errorf("invalid exclude entry %q: expected a name, match or regex", src.String())
+		}
+		if !src.IsPattern() {
+			src = Source{Match: src.Name}
+		}
+		excludeRe, err := src.pattern()
+		if err != nil {
+			return nil, err
+		}
+		excludeRes = append(excludeRes, excludeRe)
+	}
+	var result []Playlist
+	for _, playlist := range playlists {
+		if !re.MatchString(playlist.Name) {
+			continue
+		}
+		excluded := false
+		for _, excludeRe := range excludeRes {
+			if excludeRe.MatchString(playlist.Name) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			result = append(result, playlist)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
@@ -0,0 +1,62 @@
+package spotify
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceUnmarshalJSON(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		var sources []Source
+		err := json.Unmarshal([]byte(`["Road Trip", {"match": "* 2024"}, {"regex": "^Monthly"}, {"file": "tracks.csv"}]`), &sources)
+		assert.NoError(t, err)
+		expected := []Source{
+			{Name: "Road Trip"},
+			{Match: "* 2024"},
+			{Regex: "^Monthly"},
+			{File: "tracks.csv"},
+		}
+		assert.Equal(t, expected, sources, "unexpected sources")
+	})
+
+	t.Run("invalid regex", func(t *testing.T) {
+		var sources []Source
+		err := json.Unmarshal([]byte(`[{"regex": "("}]`), &sources)
+		assert.Error(t, err, "expected error for invalid regex")
+	})
+
+	t.Run("empty object", func(t *testing.T) {
+		var sources []Source
+		err := json.Unmarshal([]byte(`[{}]`), &sources)
+		assert.Error(t, err, "expected error for empty entry")
+	})
+}
+
+func TestExpand(t *testing.T) {
+	playlists := []Playlist{
+		{ID: "1", Name: "Mar 2024"},
+		{ID: "2", Name: "Feb 2024"},
+		{ID: "3", Name: "Feb 2024"},
+		{ID: "0", Name: "Feb 2024"},
+		{ID: "4", Name: "Jan 2024"},
+		{ID: "5", Name: "Jan 2023"},
+	}
+	expanded, err := expand(playlists, Source{Match: "* 2024"}, []Source{{Name: "Jan *"}})
+	assert.NoError(t, err)
+	expected := []Playlist{
+		{ID: "0", Name: "Feb 2024"},
+		{ID: "2", Name: "Feb 2024"},
+		{ID: "3", Name: "Feb 2024"},
+		{ID: "1", Name: "Mar 2024"},
+	}
+	assert.Equal(t, expected, expanded, "unexpected playlists")
+
+	t.Run("invalid exclude entries", func(t *testing.T) {
+		for _, src := range []Source{{File: "tracks.csv"}, {}} {
+			_, err := expand(playlists, Source{Match: "* 2024"}, []Source{src})
+			assert.Error(t, err, "expected error for exclude entry %+v", src)
+		}
+	})
+}
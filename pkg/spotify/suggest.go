@@ -0,0 +1,64 @@
+package spotify
+
+import (
+	"sort"
+	"strings"
+)
+
+// Caps the number of "did you mean" suggestions per playlist.
+const maxSuggestions = 3
+
+/*
+Suggest returns up to three of the candidate names that are
+closest to name, ignoring case, for "did you mean" messages.
+*/
+func Suggest(name string, candidates []string) []string {
+	type suggestion struct {
+		name     string
+		distance int
+	}
+	target := strings.ToLower(name)
+	/*
+		Allows roughly one typo per three characters,
+		so short names do not match everything.
+	*/
+	maxDistance := len([]rune(target))/3 + 1
+	var suggestions []suggestion
+	for _, candidate := range candidates {
+		lower := strings.ToLower(candidate)
+		distance := levenshtein(target, lower)
+		if distance <= maxDistance || strings.Contains(lower, target) {
+			suggestions = append(suggestions, suggestion{candidate, distance})
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].distance < suggestions[j].distance
+	})
+	var result []string
+	for i := 0; i < len(suggestions) && i < maxSuggestions; i++ {
+		result = append(result, suggestions[i].name)
+	}
+	return result
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
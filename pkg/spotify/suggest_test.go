@@ -0,0 +1,29 @@
+package spotify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"Jan 2024", "Feb 2024", "Road Trip", "Chill"}
+
+	t.Run("suggests close names", func(t *testing.T) {
+		assert.Equal(t, []string{"Road Trip"}, Suggest("road trpi", candidates))
+	})
+
+	t.Run("suggests names containing the input", func(t *testing.T) {
+		assert.Equal(t, []string{"Jan 2024", "Feb 2024"}, Suggest("2024", candidates))
+	})
+
+	t.Run("no suggestions", func(t *testing.T) {
+		assert.Empty(t, Suggest("Workout", candidates))
+	})
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, levenshtein("chill", "chill"))
+	assert.Equal(t, 1, levenshtein("chill", "chil"))
+	assert.Equal(t, 3, levenshtein("kitten", "sitting"))
+}
@@ -0,0 +1,44 @@
+package spotify
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ArtistNames returns the names of the track's artists, comma separated.
+func (t Track) ArtistNames() string {
+	var names []string
+	for _, artist := range t.Artists {
+		names = append(names, artist.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+/*
+PlaylistURI returns the URI the track is stored under in a playlist.
+It differs from URI when Spotify relinked the track for the user's market.
+*/
+func (t Track) PlaylistURI() string {
+	if t.LinkedFrom != nil && t.LinkedFrom.URI != "" {
+		return t.LinkedFrom.URI
+	}
+	return t.URI
+}
+
+// Duration returns the length of the track.
+func (t Track) Duration() time.Duration {
+	return time.Duration(t.DurationMS) * time.Millisecond
+}
+
+/*
+ReleaseYear returns the year the track's album was released,
+or 0 if Spotify does not know it, e.g. for local files.
+*/
+func (t Track) ReleaseYear() int {
+	year, err := strconv.Atoi(strings.SplitN(t.Album.ReleaseDate, "-", 2)[0])
+	if err != nil {
+		return 0
+	}
+	return year
+}
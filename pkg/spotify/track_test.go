@@ -0,0 +1,23 @@
+package spotify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrack(t *testing.T) {
+	track := Track{
+		Artists:    []Artist{{Name: "Daft Punk"}, {Name: "Pharrell Williams"}},
+		Album:      Album{ReleaseDate: "2013-05-17"},
+		DurationMS: 248413,
+	}
+	assert.Equal(t, "Daft Punk, Pharrell Williams", track.ArtistNames())
+	assert.Equal(t, 248413*time.Millisecond, track.Duration())
+	assert.Equal(t, 2013, track.ReleaseYear())
+	assert.Equal(t, 1999, Track{Album: Album{ReleaseDate: "1999"}}.ReleaseYear())
+	assert.Equal(t, 0, Track{}.ReleaseYear())
+	assert.Equal(t, "1", Track{URI: "1"}.PlaylistURI())
+	assert.Equal(t, "2", Track{URI: "1", LinkedFrom: &TrackRef{URI: "2"}}.PlaylistURI())
+}